@@ -1,41 +1,95 @@
 package analyzer
 
 import (
-	"errors"
-	"fmt"
+	"sort"
 	"unicode"
+	"unicode/utf8"
 )
 
 // ChordInfo stores request information
 //
-// Pattern must look like "01220X" from the highest string to the lowest, have length = 6
-// and consist of 'X' for muted strings and digit from 0 to 5.
+// Pattern must look like "01220X" from the highest string to the lowest, have a symbol for every string of Tuning
+// and consist of 'X' for muted strings and digit from 0 to Span.
 // If Fret is 0 the chord will be Am. If Fret == 2 and Capo == false, the chord will be A6/9sus4.
 // If Fret == 2 and Capo == true, the chord will be Bm
 //
 // Capo influences on opened strings. If it is false, open strings (0 in pattern) will be calculated as open ¯\_(ツ)_/¯
 // If true, open strings will be calculated as there is capo on Fret.
 //
-// If you want to use frets over 5th, just increase Fret value. It supports frets up to 18 (+5).
+// If you want to use frets over 5th, just increase Fret value. It supports frets up to 23, including Span.
+//
+// Span is the number of frets in diagram, from 1 to 9. If it is 0, diagram has 5 frets.
+//
+// Tuning sets open string notes in the same order as Pattern. If it is nil, standard tuning "E A D G B E" is used.
+// Number of strings is defined by Tuning, so use presets like SevenStringTuning or BassTuning for other instruments.
+// It supports up to 12 strings.
+//
+// Fingering is optional and has a symbol for every string of Pattern: digit from 1 to 4 for finger, 'T' for thumb
+// and '-' for open and muted strings, ex: "-1-23-". Use SuggestFingering to calculate it.
+//
+// Barres are optional hints. If they are nil, barres are detected from fingering.
+//
+// CapoStrings makes capo partial: it lists indexes of strings under capo in the same order as Pattern,
+// ex: []int{1, 2, 3} for short-cut capo on strings 2-4. If it is nil, capo covers all strings, empty list is invalid.
+// It is used only when Capo is true.
+//
+// CapoFret places capo independently of Fret, so Fret is only the diagram offset: capo on 2nd fret with shape
+// on 7th fret is Fret == 6, Capo == true, CapoFret == 2. If it is 0, capo is placed on Fret.
+// Fingered frets on strings under capo must not be lower than capo.
+//
+// Trace makes GetNames record every naming decision in ChordName.Steps.
+//
+// Rules sets naming rules, see ParseRules. If it is nil, DefaultRules are used.
+//
+// Key spells roots and chord tones to fit the key, ex: C#m is Dbm in Db major. If it is zero, roots are spelled
+// with sharps, except Bb and Eb.
+//
+// Locale names string labels in tab and PNG, ex: "H" for "B" in GermanLocale. Use NameStyle.Locale for chord names.
 type ChordInfo struct {
-	Pattern string
-	Fret    int
-	Capo    bool
+	Pattern     string
+	Fret        int
+	Span        int
+	Capo        bool
+	CapoFret    int
+	CapoStrings []int
+	Tuning      Tuning
+	Fingering   string
+	Barres      []Barre
+	Trace       bool
+	Rules       *Rules
+	Key         Key
+	Locale      *Locale
 }
 
 const (
-	patternLength = 6
-	maxFretNumber = 18
+	maxStringNumber = 12
+	boardFrets      = 23
+	diagramSpan     = 5
+	maxSpan         = 9
+	maxNameLength   = 20
 )
 
 // ChordNames stores information about all variations of chord, built on notes in pattern.
 //
-// Field Base is for chord with the lowest fingered string.
+// Field Base is for the most plausible chord. It is rooted on the lowest fingered string,
+// or it is slash chord with Bass, when inversion is more plausible, ex: C/E for "010230".
+//
+// Field Variations is for other chords, which can be constructed using same notes, the most plausible first.
+//
+// Field BassRooted is for chord rooted on the lowest fingered string, it is equal to Base when Base has no Bass.
+//
+// Field Barres is for barres of Barres hints or Fingering of the request. Fingering is not suggested here,
+// use FindBarres to detect barres of chord without fingering.
 //
-// Field Variations is for other chords, which can be constructed using same notes.
+// Field Strings is for notes of every string with degrees from the root of base chord,
+// and PitchClasses is for all used notes from 0 for C to 11 for B.
 type ChordNames struct {
-	Base       ChordName
-	Variations []ChordName
+	Base         ChordName    `json:"base"`
+	Variations   []ChordName  `json:"variations"`
+	BassRooted   ChordName    `json:"bassRooted"`
+	Barres       []Barre      `json:"barres"`
+	Strings      []StringNote `json:"strings"`
+	PitchClasses []int        `json:"pitchClasses"`
 }
 
 // ChordName stores information about chord construction.
@@ -53,17 +107,34 @@ type ChordNames struct {
 //
 // Omitted: this field will not be empty when the chord will not have some kind of 'third',
 // i.e. the chord will not be minor, major or suspended.
+//
+// Score: plausibility of the name from 0 to 100. Simple names with root close to bass get higher score,
+// and Variations are sorted by it.
+//
+// Intervals: semitones of chord notes from root, ex: [0 3 7 10] for Cm7;
+//
+// Degrees: labels of Intervals, ex: [R b3 5 b7] for Cm7;
+//
+// Bass: note on the lowest string of slash chord, ex: E for C/E. It is empty when root is in the bass;
+//
+// Tones: names of chord notes in order of Intervals, spelled from the root by degree, ex: [Ab C Eb] for Ab,
+// [C Eb Gb Bbb] for Cdim7;
+//
+// Steps: decisions of naming, recorded only when ChordInfo.Trace is true. See Explain.
 type ChordName struct {
-	Root     string
-	Quality  string
-	Extended string
-	Altered  string
-	Omitted  string
+	Root      string
+	Quality   string
+	Extended  string
+	Altered   string
+	Omitted   string
+	Bass      string
+	Score     int
+	Intervals []int
+	Degrees   []string
+	Tones     []string
+	Steps     []Step
 }
 
-// EmptyError can be used for preventing calculating if pattern has got no notes; ex: "XXXXXX"
-var EmptyError = errors.New("invalid request: pattern must contain at list one digit")
-
 // NewChordInfo returns new storage for request information
 func NewChordInfo(pattern string, fret int, capo bool) *ChordInfo {
 	return &ChordInfo{
@@ -85,94 +156,205 @@ func NewChordInfo(pattern string, fret int, capo bool) *ChordInfo {
 // At the end it returns struct with information about base chord, which note is on lowest string, and array of chords,
 // which can be constructed from used notes.
 func (c *ChordInfo) GetNames() (*ChordNames, error) {
-	chordPattern := newNameInfo(c.Pattern, c.Fret, c.Capo)
-	err := validate(c.Pattern, c.Fret)
+	err := c.validate()
 	if err != nil {
 		return nil, err
 	}
-	var baseChordName ChordName
-	var variations []ChordName
-	notes, baseRoot, length := chordPattern.calculateNotes()
-	for bass, intervals := range notes {
-		root, quality, extended, altered, omitted := getNames(bass, intervals, length)
-		if bass == baseRoot {
-			baseChordName = ChordName{
-				Root:     root,
-				Quality:  quality,
-				Extended: extended,
-				Altered:  altered,
-				Omitted:  omitted,
-			}
-		} else {
-			variations = append(variations, ChordName{
-				Root:     root,
-				Quality:  quality,
-				Extended: extended,
-				Altered:  altered,
-				Omitted:  omitted,
+	tuning, err := c.Tuning.notes()
+	if err != nil {
+		return nil, err
+	}
+	chordPattern := newNameInfo(c.Pattern, c.Fret, c.covered(), tuning)
+	var names []ChordName
+	var roots []int
+	notes, baseRoot, _ := chordPattern.calculateNotes()
+	for pos, bass := range chordPattern.bassOrder() {
+		name := c.rules().resolve(bass, intervalMask(notes, bass), c.Key, c.Trace)
+		name.Score = score(name, pos)
+		names = append(names, name)
+		roots = append(roots, bass)
+	}
+	bassRooted := names[0]
+	best := inversion(names, roots)
+	baseChordName := names[best]
+	if best != 0 {
+		baseChordName.Bass = slashBass(baseChordName, (baseRoot-roots[best]+12)%12)
+		if c.Trace {
+			baseChordName.Steps = append(baseChordName.Steps, Step{
+				Stage:  StageInversion,
+				Symbol: baseChordName.Bass,
+				Rule:   "bass-rooted " + bassRooted.BuildName() + " is not triad or seventh chord, and bass is chord tone of " + baseChordName.Root,
 			})
 		}
 	}
+	variations := append(names[:best:best], names[best+1:]...)
+	rank(variations)
+	barres, err := c.barres(false)
+	if err != nil {
+		return nil, err
+	}
+	var pitches []int
+	for note := 0; note < 12; note++ {
+		if notes&(1<<note) != 0 {
+			pitches = append(pitches, pitchClass(note))
+		}
+	}
+	sort.Ints(pitches)
 	return &ChordNames{
-		Base:       baseChordName,
-		Variations: variations,
+		Base:         baseChordName,
+		Variations:   variations,
+		Barres:       barres,
+		BassRooted:   bassRooted,
+		Strings:      chordPattern.stringNotes(roots[best], baseChordName),
+		PitchClasses: pitches,
 	}, nil
 }
 
-// BuildName returns string constructed from ChordName fields
-func (c *ChordName) BuildName() string {
-	var name string
-	name = c.Root
-	if c.Quality == "sus2" || c.Quality == "sus4" {
-		name += c.Extended + c.Quality
-	} else {
-		name += c.Quality + c.Extended
+// BuildName returns string constructed from ChordName fields in the given style, StandardStyle by default
+func (c *ChordName) BuildName(style ...*NameStyle) string {
+	if len(style) > 0 && style[0] != nil {
+		return style[0].build(c)
 	}
-	if c.Altered != "" {
-		name += fmt.Sprintf("(%s)", c.Altered)
-	}
-	name += c.Omitted
-	return name
+	return StandardStyle.build(c)
 }
 
-// BuildTab returns string containing chord fingering tab
+// BuildTab returns string containing chord fingering tab. Name is up to 20 characters, see NameStyle for styled names
 func (c *ChordInfo) BuildTab(name string) (string, error) {
-	if len(name) == 0 {
-		return "", errors.New("chord name can't be empty")
+	if n := utf8.RuneCountInString(name); n == 0 || n > maxNameLength {
+		return "", invalid(NameError, -1, 0, maxNameLength)
+	}
+	if err := c.validate(); err != nil {
+		return "", err
 	}
-	if len(name) > 20 {
-		return "", errors.New("chord name is too long")
+	barres, err := c.barres(true)
+	if err != nil {
+		return "", err
 	}
-	info := newTabInfo(c.Pattern, c.Fret, c.Capo)
+	info := newTabInfo(c.Pattern, c.Fret, c.span(), c.capoFret(), c.covered(), c.CapoStrings != nil, c.Locale.notes(c.Tuning.labels()), c.Fingering, barres)
 	return info.buildTab(name), nil
 }
 
+// BuildPNG returns PNG image containing chord diagram with name and string notes
 func (c *ChordInfo) BuildPNG(name string) ([]byte, error) {
-	info := newPNGInfo(name, c.Pattern, c.Fret, c.Capo)
+	return c.BuildPNGRuns([]Run{{Text: name}})
+}
+
+// BuildPNGRuns returns PNG image containing chord diagram with typeset name, see ChordName.BuildRuns
+func (c *ChordInfo) BuildPNGRuns(name []Run) ([]byte, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	barres, err := c.barres(true)
+	if err != nil {
+		return nil, err
+	}
+	info := newPNGInfo(name, c.Pattern, c.Fret, c.span(), c.capoFret(), c.covered(), c.CapoStrings != nil, c.Locale.notes(c.Tuning.labels()), c.Fingering, barres)
 	return info.buildPNG()
 }
 
-func validate(pattern string, fret int) error {
-	if len(pattern) != patternLength {
-		return lengthError
+func (c *ChordInfo) validate() error {
+	pattern, fret := c.Pattern, c.Fret
+	if err := c.Tuning.validate(); err != nil {
+		return err
+	}
+	if err := c.Key.validate(); err != nil {
+		return err
+	}
+	tuning := c.Tuning.labels()
+	if len(pattern) != len(tuning) {
+		return invalid(LengthError, -1, 0, len(tuning))
+	}
+	if c.Span < 0 || c.Span > maxSpan {
+		return invalid(SpanError, -1, 0, maxSpan)
 	}
 	countX := 0
-	for _, r := range pattern {
+	for i, r := range pattern {
 		if !unicode.IsDigit(r) {
 			if r == x {
 				countX++
 			} else {
-				return wrongSymbolsError
+				return invalid(WrongSymbolsError, i, r, 0)
 			}
-		} else if r-48 > 5 {
-			return fretPatternError
+		} else if int(r-48) > c.span() {
+			return invalid(FretPatternError, i, r, c.span())
 		}
 	}
-	if countX == patternLength {
-		return EmptyError
+	if countX == len(pattern) {
+		return invalid(EmptyError, -1, 0, 0)
 	}
-	if fret < 0 || fret > maxFretNumber {
-		return fretNumberError
+	if fret < 0 || fret+c.span() > boardFrets {
+		return invalid(FretNumberError, -1, 0, boardFrets-c.span())
 	}
-	return nil
+	if c.CapoFret < 0 || c.CapoFret > boardFrets {
+		return invalid(CapoFretError, -1, 0, boardFrets)
+	}
+	if c.Capo && c.CapoStrings != nil && len(c.CapoStrings) == 0 {
+		return invalid(CapoStringsError, -1, 0, len(pattern)-1)
+	}
+	used := make(map[int]bool)
+	for i, s := range c.CapoStrings {
+		if s < 0 || s >= len(pattern) || used[s] {
+			return invalid(CapoStringsError, i, 0, len(pattern)-1)
+		}
+		used[s] = true
+	}
+	capo := c.covered()
+	for i, r := range pattern {
+		if r != x && r != '0' && underCapo(capo, i) && fret+int(r-48) < capo[i] {
+			return invalid(CapoFretError, i, r, capo[i])
+		}
+	}
+	return validateFingering(pattern, c.Fingering)
+}
+
+// span returns number of frets in diagram
+func (c *ChordInfo) span() int {
+	if c.Span == 0 {
+		return diagramSpan
+	}
+	return c.Span
+}
+
+// rules returns naming rules of the chord
+func (c *ChordInfo) rules() *Rules {
+	if c.Rules == nil {
+		return DefaultRules()
+	}
+	return c.Rules
+}
+
+// capoFret returns position of capo, or 0 if there is no capo
+func (c *ChordInfo) capoFret() int {
+	switch {
+	case !c.Capo:
+		return 0
+	case c.CapoFret != 0:
+		return c.CapoFret
+	default:
+		return c.Fret
+	}
+}
+
+// covered returns capo fret for every string, 0 for strings without capo, or nil if there is no capo
+func (c *ChordInfo) covered() []int {
+	fret := c.capoFret()
+	if fret == 0 {
+		return nil
+	}
+	res := make([]int, len(c.Pattern))
+	for i := range res {
+		if c.CapoStrings == nil {
+			res[i] = fret
+		}
+	}
+	for _, s := range c.CapoStrings {
+		if s >= 0 && s < len(res) {
+			res[s] = fret
+		}
+	}
+	return res
+}
+
+func underCapo(capo []int, str int) bool {
+	return capo != nil && capo[str] != 0
 }
@@ -1,7 +1,12 @@
 package analyzer
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -10,89 +15,216 @@ func TestGetNames(t *testing.T) {
 	testCase := []struct {
 		pattern  string
 		fret     int
+		capo     bool
+		capoFret int
+		capoStr  []int
+		tuning   Tuning
 		expected *ChordNames
 		err      error
 	}{
-		// not predictable due to using maps. Slice order can differ
-		//{
-		//	pattern: "231231",
-		//	fret:    1,
-		//	expected: &ChordNames{
-		//		Base: &name{
-		//			Root:     "F",
-		//			Quality:  "m",
-		//			Extended: "maj13",
-		//			Altered:  "b9",
-		//			Omitted:  "",
-		//		},
-		//		Variations: []*name{
-		//			{
-		//				Root:     "C",
-		//				Quality:  "aug",
-		//				Extended: "",
-		//				Altered:  "b5,add9,add11",
-		//				Omitted:  "",
-		//			},
-		//			{
-		//				Root:     "E",
-		//				Quality:  "",
-		//				Extended: "9",
-		//				Altered:  "b9",
-		//				Omitted:  "b13",
-		//			},
-		//			{
-		//				Root:     "Ab",
-		//				Quality:  "",
-		//				Extended: "13",
-		//				Altered:  "#5,#11",
-		//				Omitted:  "",
-		//			},
-		//			{
-		//				Root:     "D",
-		//				Quality:  "",
-		//				Extended: "9",
-		//				Altered:  "#9",
-		//				Omitted:  "#11",
-		//			},
-		//			{
-		//				Root:     "F#",
-		//				Quality:  "sus2",
-		//				Extended: "7,maj7",
-		//				Altered:  "#11,b13",
-		//				Omitted:  "",
-		//			},
-		//		},
-		//	},
-		//	err: nil,
-		//},
+		{
+			pattern: "00023X",
+			fret:    2,
+			capo:    true,
+			expected: &ChordNames{
+				Base: ChordName{Root: "D", Extended: "maj7", Score: 96, Intervals: []int{0, 4, 7, 11}, Degrees: []string{"R", "3", "5", "7"}, Tones: []string{"D", "F#", "A", "C#"}},
+				Variations: []ChordName{
+					{Root: "F#", Quality: "m", Extended: "b6", Score: 88, Intervals: []int{0, 3, 7, 8}, Degrees: []string{"R", "b3", "5", "b6"}, Tones: []string{"F#", "A", "C#", "D"}},
+					{Root: "A", Extended: "6/11", Score: 76, Intervals: []int{0, 4, 5, 9}, Degrees: []string{"R", "3", "11", "6"}, Tones: []string{"A", "C#", "D", "F#"}},
+					{Root: "C#", Quality: "sus4", Extended: "b6", Altered: "b9", Score: 56, Intervals: []int{0, 1, 5, 8}, Degrees: []string{"R", "b9", "4", "b6"}, Tones: []string{"C#", "D", "F#", "A"}},
+				},
+			},
+			err: nil,
+		},
 		{
 			pattern: "XXX20X",
 			fret:    4,
+			capo:    true,
+			expected: &ChordNames{
+				Base: ChordName{
+					Root:      "C#",
+					Quality:   "",
+					Extended:  "5",
+					Altered:   "",
+					Omitted:   "",
+					Score:     100,
+					Intervals: []int{0, 7},
+					Degrees:   []string{"R", "5"},
+					Tones:     []string{"C#", "G#"},
+				},
+				Variations: []ChordName{
+					{
+						Root:      "G#",
+						Quality:   "sus4",
+						Extended:  "",
+						Altered:   "",
+						Omitted:   "",
+						Score:     86,
+						Intervals: []int{0, 5},
+						Degrees:   []string{"R", "4"},
+						Tones:     []string{"G#", "C#"},
+					},
+				},
+			},
+			err: nil,
+		},
+		{
+			pattern: "XXXX00",
+			fret:    0,
+			tuning:  DropDTuning,
+			expected: &ChordNames{
+				Base: ChordName{
+					Root:      "D",
+					Quality:   "",
+					Extended:  "5",
+					Altered:   "",
+					Omitted:   "",
+					Score:     100,
+					Intervals: []int{0, 7},
+					Degrees:   []string{"R", "5"},
+					Tones:     []string{"D", "A"},
+				},
+				Variations: []ChordName{
+					{
+						Root:      "A",
+						Quality:   "sus4",
+						Extended:  "",
+						Altered:   "",
+						Omitted:   "",
+						Score:     86,
+						Intervals: []int{0, 5},
+						Degrees:   []string{"R", "4"},
+						Tones:     []string{"A", "D"},
+					},
+				},
+			},
+			err: nil,
+		},
+		{
+			pattern: "XX32",
+			fret:    0,
+			tuning:  BassTuning,
+			expected: &ChordNames{
+				Base: ChordName{
+					Root:      "F#",
+					Quality:   "",
+					Extended:  "",
+					Altered:   "b5",
+					Omitted:   "no3",
+					Score:     78,
+					Intervals: []int{0, 6},
+					Degrees:   []string{"R", "b5"},
+					Tones:     []string{"F#", "C"},
+				},
+				Variations: []ChordName{
+					{
+						Root:      "C",
+						Quality:   "",
+						Extended:  "",
+						Altered:   "b5",
+						Omitted:   "no3",
+						Score:     70,
+						Intervals: []int{0, 6},
+						Degrees:   []string{"R", "b5"},
+						Tones:     []string{"C", "Gb"},
+					},
+				},
+			},
+			err: nil,
+		},
+		{
+			pattern: "X00XXX",
+			fret:    2,
+			capo:    true,
+			capoStr: []int{1},
+			expected: &ChordNames{
+				Base: ChordName{
+					Root:      "G",
+					Quality:   "",
+					Extended:  "",
+					Altered:   "b5",
+					Omitted:   "no3",
+					Score:     78,
+					Intervals: []int{0, 6},
+					Degrees:   []string{"R", "b5"},
+					Tones:     []string{"G", "Db"},
+				},
+				Variations: []ChordName{
+					{
+						Root:      "C#",
+						Quality:   "",
+						Extended:  "",
+						Altered:   "b5",
+						Omitted:   "no3",
+						Score:     70,
+						Intervals: []int{0, 6},
+						Degrees:   []string{"R", "b5"},
+						Tones:     []string{"C#", "G"},
+					},
+				},
+			},
+			err: nil,
+		},
+		{
+			pattern:  "X0XXX0",
+			fret:     7,
+			capo:     true,
+			capoFret: 2,
 			expected: &ChordNames{
 				Base: ChordName{
-					Root:     "C#",
-					Quality:  "",
-					Extended: "5",
-					Altered:  "",
-					Omitted:  "",
+					Root:      "F#",
+					Quality:   "",
+					Extended:  "5",
+					Altered:   "",
+					Omitted:   "",
+					Score:     100,
+					Intervals: []int{0, 7},
+					Degrees:   []string{"R", "5"},
+					Tones:     []string{"F#", "C#"},
 				},
 				Variations: []ChordName{
 					{
-						Root:     "G#",
-						Quality:  "sus4",
-						Extended: "",
-						Altered:  "",
-						Omitted:  "",
+						Root:      "C#",
+						Quality:   "sus4",
+						Extended:  "",
+						Altered:   "",
+						Omitted:   "",
+						Score:     86,
+						Intervals: []int{0, 5},
+						Degrees:   []string{"R", "4"},
+						Tones:     []string{"C#", "F#"},
 					},
 				},
 			},
 			err: nil,
 		},
+		{
+			pattern:  "X1XXXX",
+			fret:     0,
+			capo:     true,
+			capoFret: 3,
+			expected: nil,
+			err:      CapoFretError,
+		},
+		{
+			pattern:  "X15XXX",
+			fret:     1,
+			capo:     true,
+			capoStr:  []int{1, 6},
+			expected: nil,
+			err:      CapoStringsError,
+		},
+		{
+			pattern:  "X16XXX",
+			fret:     1,
+			expected: nil,
+			err:      FretPatternError,
+		},
 		{
 			pattern:  "X123452X",
 			fret:     1,
 			expected: nil,
-			err:      lengthError,
+			err:      LengthError,
 		},
 		{
 			pattern:  "XXXXXX",
@@ -104,21 +236,1103 @@ func TestGetNames(t *testing.T) {
 			pattern:  "XY456X",
 			fret:     1,
 			expected: nil,
-			err:      wrongSymbolsError,
+			err:      WrongSymbolsError,
 		},
 		{
 			pattern:  "X15XXX",
 			fret:     25,
 			expected: nil,
-			err:      fretNumberError,
+			err:      FretNumberError,
+		},
+		{
+			pattern:  "X15XXX",
+			fret:     1,
+			tuning:   Tuning{"E", "B", "G", "D"},
+			expected: nil,
+			err:      LengthError,
+		},
+		{
+			pattern:  "X15XXXXXXXXXX",
+			fret:     1,
+			tuning:   Tuning{"E", "B", "G", "D", "A", "E", "B", "E", "B", "G", "D", "A", "E"},
+			expected: nil,
+			err:      StringNumberError,
 		},
 	}
 	for _, r := range testCase {
-		chord := NewChordInfo(r.pattern, r.fret, false)
+		chord := NewChordInfo(r.pattern, r.fret, r.capo)
+		chord.Tuning = r.tuning
+		chord.CapoStrings = r.capoStr
+		chord.CapoFret = r.capoFret
 		actual, err := chord.GetNames()
+		if actual != nil {
+			// string notes are checked in TestStringNotes, slash chords in TestInversions
+			if actual.Base.Bass == "" {
+				assert.Equal(t, actual.Base, actual.BassRooted)
+			}
+			actual.Strings, actual.PitchClasses, actual.BassRooted = nil, nil, ChordName{}
+		}
 		assert.Equal(t, r.expected, actual)
 		if r.err != nil {
-			assert.EqualError(t, err, r.err.Error())
+			assert.ErrorIs(t, err, r.err)
 		}
 	}
 }
+
+func TestParseTuning(t *testing.T) {
+	testCase := []struct {
+		tuning   string
+		expected Tuning
+		err      error
+	}{
+		{
+			tuning:   "E A D G B E",
+			expected: StandardTuning,
+		},
+		{
+			tuning:   "DADGAD",
+			expected: DADGADTuning,
+		},
+		{
+			tuning:   "EbAbDbGbBbEb",
+			expected: HalfStepDownTuning,
+		},
+		{
+			tuning:   "d-g-d-g-b-d",
+			expected: OpenGTuning,
+		},
+		{
+			tuning: "E A H G B E",
+			err:    TuningNoteError,
+		},
+	}
+	for _, r := range testCase {
+		actual, err := ParseTuning(r.tuning)
+		assert.Equal(t, r.expected, actual)
+		if r.err != nil {
+			assert.ErrorIs(t, err, r.err)
+		}
+	}
+}
+
+func TestParseFrets(t *testing.T) {
+	testCase := []struct {
+		frets     string
+		formatted string
+		expected  *ChordInfo
+		err       error
+	}{
+		{
+			frets:    "x32010",
+			expected: NewChordInfo("01023X", 0, false),
+		},
+		{
+			frets:    "x-10-12-12-11-x",
+			expected: NewChordInfo("X2331X", 9, false),
+		},
+		{
+			frets:     "8 10 10 9 8 8",
+			formatted: "8-10-10-9-8-8",
+			expected:  NewChordInfo("112331", 7, false),
+		},
+		{
+			frets:     "0-7-9-9-x-0",
+			formatted: "0799x0",
+			expected:  NewChordInfo("0X3310", 6, false),
+		},
+		{
+			frets:     "x-3-10-x-x-x",
+			formatted: "x-3-10-x-x-x",
+			expected:  &ChordInfo{Pattern: "XXX81X", Fret: 2, Span: 8},
+		},
+		{
+			frets: "x-1-12-x-x-x",
+			err:   FretsSpanError,
+		},
+		{
+			frets:    "x-20-22-22-21-x",
+			expected: NewChordInfo("X3442X", 18, false),
+		},
+		{
+			frets:     "23 23 23 23 23 23",
+			formatted: "23-23-23-23-23-23",
+			expected:  NewChordInfo("555555", 18, false),
+		},
+		{
+			frets: "x3201o",
+			err:   FretsSymbolError,
+		},
+		{
+			frets: "x-24-x-x-x-x",
+			err:   FretsRangeError,
+		},
+		{
+			frets: " ",
+			err:   EmptyError,
+		},
+	}
+	for _, r := range testCase {
+		actual, err := ParseFrets(r.frets)
+		assert.Equal(t, r.expected, actual)
+		if r.err != nil {
+			assert.ErrorIs(t, err, r.err)
+			var vErr *ValidationError
+			assert.ErrorAs(t, err, &vErr)
+			assert.Equal(t, kinds[r.err], vErr.Kind)
+		} else if r.formatted != "" {
+			assert.Equal(t, r.formatted, actual.Frets())
+		} else {
+			assert.Equal(t, r.frets, actual.Frets())
+		}
+		if err == nil {
+			_, err = actual.GetNames()
+			assert.NoError(t, err, r.frets)
+		}
+	}
+}
+
+func TestSuggestFingering(t *testing.T) {
+	testCase := []struct {
+		pattern   string
+		fret      int
+		fingering string
+		expected  string
+		err       error
+	}{
+		{
+			pattern:  "01023X",
+			expected: "-1-23-",
+		},
+		{
+			pattern:  "112331",
+			expected: "112431",
+		},
+		{
+			pattern:  "24442X",
+			fret:     0,
+			expected: "14321-",
+		},
+		{
+			pattern: "12345X",
+			err:     FingeringError,
+		},
+		{
+			pattern:   "01023X",
+			fingering: "-1-2--",
+			err:       FingeringMatchError,
+		},
+		{
+			pattern: "X17XXX",
+			err:     FretPatternError,
+		},
+	}
+	for _, r := range testCase {
+		chord := NewChordInfo(r.pattern, r.fret, false)
+		chord.Fingering = r.fingering
+		actual, err := chord.SuggestFingering()
+		assert.Equal(t, r.expected, actual)
+		if r.err != nil {
+			assert.ErrorIs(t, err, r.err)
+			var vErr *ValidationError
+			assert.Equal(t, r.err != FingeringError, errors.As(err, &vErr), r.pattern)
+		}
+	}
+}
+
+func TestFindBarres(t *testing.T) {
+	testCase := []struct {
+		pattern  string
+		hints    []Barre
+		expected []Barre
+		err      error
+	}{
+		{
+			pattern:  "112331",
+			expected: []Barre{{Fret: 1, From: 0, To: 5, Full: true}},
+		},
+		{
+			pattern:  "24442X",
+			expected: []Barre{{Fret: 2, From: 0, To: 4}},
+		},
+		{
+			pattern: "01023X",
+		},
+		{
+			pattern:  "23222X",
+			hints:    []Barre{{Fret: 2, From: 4, To: 2}},
+			expected: []Barre{{Fret: 2, From: 2, To: 4}},
+		},
+		{
+			pattern: "01023X",
+			hints:   []Barre{{Fret: 1, From: 0, To: 1}},
+			err:     BarreError,
+		},
+	}
+	for _, r := range testCase {
+		chord := NewChordInfo(r.pattern, 0, false)
+		chord.Barres = r.hints
+		actual, err := chord.FindBarres()
+		assert.Equal(t, r.expected, actual)
+		if r.err != nil {
+			assert.ErrorIs(t, err, r.err)
+		}
+	}
+	// GetNames does not suggest fingering, barres come from Fingering or hints
+	chord := NewChordInfo("112331", 0, false)
+	names, err := chord.GetNames()
+	assert.NoError(t, err)
+	assert.Nil(t, names.Barres)
+	chord.Fingering = "113421"
+	names, err = chord.GetNames()
+	assert.NoError(t, err)
+	assert.Equal(t, []Barre{{Fret: 1, From: 0, To: 5, Full: true}}, names.Barres)
+}
+
+func TestValidationError(t *testing.T) {
+	testCase := []struct {
+		chord    *ChordInfo
+		expected *ValidationError
+	}{
+		{
+			chord:    NewChordInfo("X1Y23X", 0, false),
+			expected: &ValidationError{Kind: KindSymbol, Index: 2, Rune: 'Y', err: WrongSymbolsError},
+		},
+		{
+			chord:    NewChordInfo("X17XXX", 0, false),
+			expected: &ValidationError{Kind: KindPatternFret, Index: 2, Rune: '7', Limit: 5, err: FretPatternError},
+		},
+		{
+			chord:    &ChordInfo{Pattern: "X1XXX", Tuning: Tuning{"E", "B", "H", "D", "A"}},
+			expected: &ValidationError{Kind: KindTuning, Index: 2, Rune: 'H', err: TuningNoteError},
+		},
+		{
+			chord:    &ChordInfo{Pattern: "X2331X", Fingering: "-24-1-"},
+			expected: &ValidationError{Kind: KindFingering, Index: 3, Rune: '-', err: FingeringMatchError},
+		},
+		{
+			chord:    &ChordInfo{Pattern: "X2331X", Capo: true, CapoStrings: []int{1, -1}},
+			expected: &ValidationError{Kind: KindCapoStrings, Index: 1, Limit: 5, err: CapoStringsError},
+		},
+	}
+	for _, r := range testCase {
+		_, err := r.chord.BuildPNG("C")
+		var actual *ValidationError
+		assert.ErrorAs(t, err, &actual)
+		assert.Equal(t, r.expected, actual)
+		assert.ErrorIs(t, err, r.expected.err)
+	}
+	_, err := (&ChordInfo{Pattern: "X2331X", Capo: true, CapoStrings: []int{1, -1}}).GetNames()
+	assert.EqualError(t, err, CapoStringsError.Error()+": capo strings entry 1: limit 5")
+}
+
+func TestStringNotes(t *testing.T) {
+	chord := NewChordInfo("01023X", 0, false)
+	names, err := chord.GetNames()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 4, 7}, names.PitchClasses)
+	assert.Equal(t, []StringNote{
+		{String: 0, Fret: 0, Note: "E", PitchClass: 4, Degree: "3"},
+		{String: 1, Fret: 1, Note: "C", PitchClass: 0, Degree: "R"},
+		{String: 2, Fret: 0, Note: "G", PitchClass: 7, Degree: "5"},
+		{String: 3, Fret: 2, Note: "E", PitchClass: 4, Degree: "3"},
+		{String: 4, Fret: 3, Note: "C", PitchClass: 0, Degree: "R"},
+		{String: 5, Muted: true, Fret: -1, PitchClass: -1},
+	}, names.Strings)
+}
+
+func TestSpell(t *testing.T) {
+	testCase := []struct {
+		root     string
+		iv       int
+		degree   string
+		expected string
+	}{
+		{root: "Ab", iv: majThird, degree: "3", expected: "C"},
+		{root: "Ab", iv: perfectFifth, degree: "5", expected: "Eb"},
+		{root: "G#", iv: majThird, degree: "3", expected: "B#"},
+		{root: "C", iv: sixth, degree: "bb7", expected: "Bbb"},
+		{root: "C#", iv: sixth, degree: "bb7", expected: "Bb"},
+		{root: "C", iv: minThird, degree: "#9", expected: "D#"},
+		{root: "Gb", iv: perfectFourth, degree: "4", expected: "Cb"},
+		{root: "Db", iv: flatFifth, degree: "b5", expected: "Abb"},
+		{root: "E", iv: flatFifth, degree: "#11", expected: "A#"},
+	}
+	for _, r := range testCase {
+		assert.Equal(t, r.expected, spell(r.root, r.iv, r.degree))
+	}
+}
+
+func TestRootSpelling(t *testing.T) {
+	testCase := []struct {
+		frets    string
+		root     int
+		interval []int
+		expected string
+	}{
+		{frets: "2-4-4-3-2-2", expected: "F#"},
+		{frets: "x-4-6-6-6-4", expected: "C#"},
+		{frets: "4-6-6-5-4-4", expected: "G#"},
+		{frets: "6-8-8-7-6-6", expected: "Bb"},
+		{frets: "x-6-8-8-8-6", expected: "Eb"},
+		{root: 9, interval: []int{0, 4, 7, 9}, expected: "C#6"},
+		{root: 4, interval: []int{0, 4, 7, 8}, expected: "G#b6"},
+		{root: 2, interval: []int{0, 4, 7, 10}, expected: "F#7"},
+		{root: 6, interval: []int{0, 4, 7, 8}, expected: "Bb(b6)"},
+		{root: 11, interval: []int{0, 2, 5, 7, 8}, expected: "Ebb6/9sus4"},
+	}
+	rules := DefaultRules()
+	for _, r := range testCase {
+		if r.frets != "" {
+			chord, err := ParseFrets(r.frets)
+			assert.NoError(t, err, r.frets)
+			names, err := chord.GetNames()
+			assert.NoError(t, err, r.frets)
+			assert.Equal(t, r.expected, names.Base.BuildName(), r.frets)
+			continue
+		}
+		var mask uint16
+		for _, iv := range r.interval {
+			mask |= 1 << iv
+		}
+		name := rules.resolve(r.root, mask, Key{}, false)
+		assert.Equal(t, r.expected, name.BuildName())
+	}
+}
+
+func TestParseNameRoundTrip(t *testing.T) {
+	testCase := []struct {
+		pattern string
+		fret    int
+		capo    bool
+		key     Key
+		name    string
+	}{
+		{pattern: "231231", fret: 1, key: Key{Tonic: "Db"}, name: "Db(b6/9/11,b5)"},
+		{pattern: "XX1121", name: "Ebb6/9sus4"},
+		{pattern: "122331", fret: 5, name: "Bb(b6)"},
+		{pattern: "1211XX", key: Key{Tonic: "Db"}, name: "Db(add9)"},
+		{pattern: "00023X", fret: 2, capo: true, name: "Dmaj7"},
+	}
+	for _, r := range testCase {
+		chord := NewChordInfo(r.pattern, r.fret, r.capo)
+		chord.Key = r.key
+		names, err := chord.GetNames()
+		assert.NoError(t, err, r.pattern)
+		var built []string
+		for _, name := range append([]ChordName{names.Base, names.BassRooted}, names.Variations...) {
+			symbol := name.BuildName()
+			built = append(built, symbol)
+			// natural root with flat extension, like E with b6, reads as flat root
+			if len(name.Root) == 1 && strings.HasPrefix(symbol[1:], "b") {
+				continue
+			}
+			parsed, err := ParseName(symbol)
+			assert.NoError(t, err, symbol)
+			if err != nil {
+				continue
+			}
+			assert.Equal(t, name.Root, parsed.Name.Root, symbol)
+			assert.Equal(t, name.Quality, parsed.Name.Quality, symbol)
+			assert.Equal(t, name.Extended, parsed.Name.Extended, symbol)
+			assert.Equal(t, name.Altered, parsed.Name.Altered, symbol)
+			assert.Equal(t, name.Omitted, parsed.Name.Omitted, symbol)
+			assert.Equal(t, name.Bass, parsed.Name.Bass, symbol)
+			assert.Equal(t, symbol, parsed.Name.BuildName())
+		}
+		assert.Contains(t, built, r.name, r.pattern)
+	}
+	parsed, err := ParseName("Bb(b6/9)")
+	assert.NoError(t, err)
+	assert.Equal(t, "b6/9", parsed.Name.Altered)
+	assert.Equal(t, []int{0, 2, 4, 7, 8}, parsed.Name.Intervals)
+}
+
+func TestParseName(t *testing.T) {
+	testCase := []struct {
+		symbol    string
+		name      string
+		bass      string
+		intervals []int
+		pitches   []int
+		err       error
+	}{
+		{symbol: "Cm7(b9)/G", name: "Cm7(b9)/G", bass: "G", intervals: []int{0, 1, 3, 7, 10}, pitches: []int{0, 1, 3, 7, 10}},
+		{symbol: "F#7#9", name: "F#7(#9)", intervals: []int{0, 3, 4, 7, 10}, pitches: []int{1, 4, 6, 9, 10}},
+		{symbol: "Bbmaj13#11", name: "Bbmaj13(#11)", intervals: []int{0, 4, 6, 7, 9, 11}, pitches: []int{2, 4, 5, 7, 9, 10}},
+		{symbol: "C6/9", name: "C6/9", intervals: []int{0, 2, 4, 7, 9}, pitches: []int{0, 2, 4, 7, 9}},
+		{symbol: "C#sus4(#5,b9)", name: "C#sus4(#5,b9)", intervals: []int{0, 1, 5, 8}, pitches: []int{1, 2, 6, 9}},
+		{symbol: "C-7", name: "Cm7", intervals: []int{0, 3, 7, 10}, pitches: []int{0, 3, 7, 10}},
+		{symbol: "Cø", name: "Cm7(b5)", intervals: []int{0, 3, 6, 10}, pitches: []int{0, 3, 6, 10}},
+		{symbol: "Cø7", name: "Cm7(b5)", intervals: []int{0, 3, 6, 10}, pitches: []int{0, 3, 6, 10}},
+		{symbol: "C°7", name: "Cdim7", intervals: []int{0, 3, 6, 9}, pitches: []int{0, 3, 6, 9}},
+		{symbol: "Eno3", name: "Eno3", intervals: []int{0, 7}, pitches: []int{4, 11}},
+		{symbol: "f#7/C#", name: "F#m7/C#", bass: "C#", intervals: []int{0, 3, 7, 10}, pitches: []int{1, 4, 6, 9}},
+		{symbol: "bb°7", name: "Bbdim7", intervals: []int{0, 3, 6, 9}, pitches: []int{1, 4, 7, 10}},
+		{symbol: "c+", err: NameRootError},
+		{symbol: "H7", err: NameRootError},
+		{symbol: "Cx7", err: NameTokenError},
+		{symbol: "C/Gx", err: NameBassError},
+	}
+	for _, r := range testCase {
+		actual, err := ParseName(r.symbol)
+		if r.err != nil {
+			assert.ErrorIs(t, err, r.err, r.symbol)
+			continue
+		}
+		assert.NoError(t, err, r.symbol)
+		assert.Equal(t, r.name, actual.Name.BuildName(), r.symbol)
+		assert.Equal(t, r.bass, actual.Name.Bass, r.symbol)
+		assert.Equal(t, r.intervals, actual.Name.Intervals, r.symbol)
+		assert.Equal(t, r.pitches, actual.PitchClasses, r.symbol)
+	}
+}
+
+func TestNameStyle(t *testing.T) {
+	custom := &NameStyle{Extensions: map[string]string{"maj7": "M7"}, AlterOpen: "[", AlterClose: "]", AlterSeparator: " ", Slash: " over "}
+	testCase := []struct {
+		symbol string
+		styles map[*NameStyle]string
+	}{
+		{symbol: "Cmaj7", styles: map[*NameStyle]string{StandardStyle: "Cmaj7", JazzStyle: "CΔ7", RealBookStyle: "Cma7", BerkleeStyle: "CMaj7", ClassicalStyle: "Cmaj7"}},
+		{symbol: "Cm7", styles: map[*NameStyle]string{JazzStyle: "C-7", RealBookStyle: "Cmi7", BerkleeStyle: "C-7", ClassicalStyle: "c7"}},
+		{symbol: "Cm7(b5)", styles: map[*NameStyle]string{JazzStyle: "Cø7", RealBookStyle: "Cmi7(b5)", BerkleeStyle: "C-7(b5)", ClassicalStyle: "cø7"}},
+		{symbol: "Cm(b5)", styles: map[*NameStyle]string{JazzStyle: "C-(b5)", ClassicalStyle: "c(b5)"}},
+		{symbol: "E(b5)", styles: map[*NameStyle]string{JazzStyle: "E(b5)", ClassicalStyle: "E(b5)"}},
+		{symbol: "Caug(b9,#9)", styles: map[*NameStyle]string{JazzStyle: "C+(b9,#9)"}},
+		{symbol: "Cm9(b5)", styles: map[*NameStyle]string{JazzStyle: "Cø9", ClassicalStyle: "cø9"}},
+		{symbol: "Cm6(b5)", styles: map[*NameStyle]string{JazzStyle: "C-6b5", ClassicalStyle: "c6(b5)"}},
+		{symbol: "Cmmaj7(b5)", styles: map[*NameStyle]string{JazzStyle: "C-Δ7b5", ClassicalStyle: "cmaj7(b5)"}},
+		{symbol: "Cmb6(b5)", styles: map[*NameStyle]string{JazzStyle: "C-b6b5", ClassicalStyle: "cmb6(b5)"}},
+		{symbol: "F#dim7", styles: map[*NameStyle]string{JazzStyle: "F#°7", BerkleeStyle: "F#o7", ClassicalStyle: "f#°7"}},
+		{symbol: "Caug", styles: map[*NameStyle]string{JazzStyle: "C+", RealBookStyle: "Caug", ClassicalStyle: "C+"}},
+		{symbol: "C7sus4", styles: map[*NameStyle]string{JazzStyle: "C7sus", RealBookStyle: "C7sus", BerkleeStyle: "C7sus4"}},
+		{symbol: "C7(b9,#11)", styles: map[*NameStyle]string{JazzStyle: "C7b9#11", RealBookStyle: "C7(b9,#11)"}},
+		{symbol: "C9,maj9", styles: map[*NameStyle]string{JazzStyle: "C9,Δ9"}},
+		{symbol: "Cmaj7(#11)/E", styles: map[*NameStyle]string{JazzStyle: "CΔ7#11/E", custom: "CM7[#11] over E"}},
+	}
+	for _, r := range testCase {
+		chord, err := ParseName(r.symbol)
+		assert.NoError(t, err, r.symbol)
+		assert.Equal(t, r.symbol, chord.Name.BuildName(), r.symbol)
+		assert.Equal(t, r.symbol, chord.Name.BuildName(nil), r.symbol)
+		for style, name := range r.styles {
+			assert.Equal(t, name, chord.Name.BuildName(style), r.symbol)
+			if style == custom {
+				continue
+			}
+			parsed, err := ParseName(name)
+			if assert.NoError(t, err, name) {
+				assert.Equal(t, r.symbol, parsed.Name.BuildName(), name)
+			}
+		}
+	}
+}
+
+func TestNameStyleMajor(t *testing.T) {
+	// major quality is replaced in styles without half-diminished symbol
+	style := &NameStyle{Qualities: map[string]string{"": "M", "m": "m"}, Slash: slash}
+	chord, err := ParseName("C7/E")
+	assert.NoError(t, err)
+	assert.Equal(t, "CM7/E", chord.Name.BuildName(style))
+	style.HalfDiminished = "ø"
+	assert.Equal(t, "CM7/E", chord.Name.BuildName(style))
+	chord, err = ParseName("Cm7(b5)")
+	assert.NoError(t, err)
+	assert.Equal(t, "Cø7", chord.Name.BuildName(style))
+}
+
+func TestLocale(t *testing.T) {
+	german := &NameStyle{AlterOpen: "(", AlterClose: ")", AlterSeparator: comma, Slash: slash, Locale: GermanLocale}
+	chart := &Locale{Notes: GermanLocale.Notes, Sharp: "is", Flat: "es"}
+	testCase := []struct {
+		symbol string
+		styles map[*NameStyle]string
+	}{
+		{symbol: "F#m/C#", styles: map[*NameStyle]string{german: "Fis-Moll/Cis", {Slash: slash, Locale: SolfegeLocale}: "Fa# minore/Do#",
+			{Slash: slash, Locale: chart}: "Fism/Cis"}},
+		{symbol: "Bb7", styles: map[*NameStyle]string{german: "B7", {Locale: FrenchLocale}: "Sib7"}},
+		{symbol: "Bmaj7", styles: map[*NameStyle]string{german: "Hmaj7", {Locale: SolfegeLocale}: "Simaj7"}},
+		{symbol: "Ebm7(b5)", styles: map[*NameStyle]string{german: "Esm7(b5)", {Locale: FrenchLocale, HalfDiminished: "ø"}: "Mibø7"}},
+		{symbol: "Dbsus4", styles: map[*NameStyle]string{german: "Dessus4"}},
+		{symbol: "F#m", styles: map[*NameStyle]string{german: "Fis-Moll", {Locale: GermanLocale, LowerMinor: true}: "fis-Moll",
+			{Locale: chart}: "Fism"}},
+		{symbol: "Ab", styles: map[*NameStyle]string{german: "As-Dur", {Locale: SolfegeLocale}: "Lab maggiore", {Locale: FrenchLocale}: "Lab majeur"}},
+		{symbol: "Dm", styles: map[*NameStyle]string{{Locale: SolfegeLocale}: "Re minore", {Locale: FrenchLocale}: "Ré mineur"}},
+		{symbol: "C7", styles: map[*NameStyle]string{german: "C7"}},
+	}
+	for _, r := range testCase {
+		chord, err := ParseName(r.symbol)
+		assert.NoError(t, err, r.symbol)
+		for style, name := range r.styles {
+			assert.Equal(t, name, chord.Name.BuildName(style), r.symbol)
+		}
+	}
+	assert.Equal(t, "Heses", GermanLocale.Note("Bbb"))
+	assert.Equal(t, "Fisis", GermanLocale.Note("F##"))
+	assert.Equal(t, "Ré#", FrenchLocale.Note("D#"))
+	assert.Equal(t, "Bb", (*Locale)(nil).Note("Bb"))
+	tab, err := (&ChordInfo{Pattern: "01023X", Tuning: DropDTuning, Locale: SolfegeLocale}).BuildTab("Do")
+	assert.NoError(t, err)
+	assert.Equal(t, `Do
+Mi  0|---|---|---|---|---|
+Si  -|-#-|---|---|---|---|
+Sol 0|---|---|---|---|---|
+Re  -|---|-#-|---|---|---|
+La  -|---|---|-#-|---|---|
+Re  X|---|---|---|---|---|
+       1   2   3   4   5 `, strings.ReplaceAll(tab, space, " "))
+}
+
+func TestMusicXML(t *testing.T) {
+	testCase := []struct {
+		symbol string
+		kind   string
+		bass   string
+		degree []string
+	}{
+		{symbol: "C#m7(b5)/G", kind: `<root-step>C</root-step><root-alter>1</root-alter></root><kind text="m7b5">half-diminished</kind>`,
+			bass: `<bass-step>G</bass-step>`},
+		{symbol: "Bb7(b9,#11)", kind: `<root-step>B</root-step><root-alter>-1</root-alter></root><kind text="7">dominant</kind>`,
+			degree: []string{"9 -1 add", "11 1 add"}},
+		{symbol: "C13(b9)", kind: `<kind text="13">dominant-13th</kind>`, degree: []string{"9 -1 alter"}},
+		{symbol: "Cdim9", kind: `<kind text="dim9">diminished-seventh</kind>`, degree: []string{"9 0 add"}},
+		{symbol: "C6/9", kind: `<kind text="6/9">major-sixth</kind>`, degree: []string{"9 0 add"}},
+		{symbol: "Cmmaj9", kind: `<kind text="mmaj9">major-minor</kind>`, degree: []string{"9 0 add"}},
+		{symbol: "C7sus4", kind: `<kind text="7sus4">suspended-fourth</kind>`, degree: []string{"7 -1 add"}},
+		{symbol: "Cm(add9)", kind: `<kind text="m">minor</kind>`, degree: []string{"9 0 add"}},
+		{symbol: "Caug(#9)", kind: `<kind text="aug">augmented</kind>`, degree: []string{"9 1 add"}},
+		{symbol: "C7(#5)", kind: `<kind text="7">dominant</kind>`, degree: []string{"5 1 alter"}},
+		{symbol: "Cno3", kind: `<kind text="">major</kind>`, degree: []string{"3 0 subtract"}},
+		{symbol: "E5", kind: `<kind text="5">power</kind>`},
+	}
+	compact := strings.NewReplacer("\n", "", "  ", "")
+	for _, r := range testCase {
+		chord, err := ParseName(r.symbol)
+		assert.NoError(t, err, r.symbol)
+		data, err := chord.Name.BuildMusicXML()
+		assert.NoError(t, err, r.symbol)
+		actual := compact.Replace(string(data))
+		assert.Contains(t, actual, r.kind, r.symbol)
+		if r.bass != "" {
+			assert.Contains(t, actual, "<bass>"+r.bass+"</bass>", r.symbol)
+		}
+		assert.Equal(t, len(r.degree), strings.Count(actual, "<degree>"), r.symbol)
+		for _, d := range r.degree {
+			var value, alter int
+			var typ string
+			_, err := fmt.Sscan(d, &value, &alter, &typ)
+			assert.NoError(t, err)
+			assert.Contains(t, actual, fmt.Sprintf("<degree><degree-value>%d</degree-value><degree-alter>%d</degree-alter><degree-type>%s</degree-type></degree>",
+				value, alter, typ), r.symbol)
+		}
+	}
+	// names from GetNames, flat sixth compounds are split into degrees
+	for _, r := range []struct {
+		pattern string
+		fret    int
+		key     Key
+	}{
+		{pattern: "231231", fret: 1, key: Key{Tonic: "Db"}},
+		{pattern: "XX1121"},
+		{pattern: "122331", fret: 5},
+	} {
+		chord := NewChordInfo(r.pattern, r.fret, false)
+		chord.Key = r.key
+		names, err := chord.GetNames()
+		assert.NoError(t, err, r.pattern)
+		for _, name := range append([]ChordName{names.Base, names.BassRooted}, names.Variations...) {
+			_, err := name.BuildMusicXML()
+			assert.NoError(t, err, name.BuildName())
+		}
+	}
+	data, err := (&ChordName{Root: "Db", Altered: "b6/9/11,b5"}).BuildMusicXML()
+	assert.NoError(t, err)
+	assert.Contains(t, compact.Replace(string(data)), "<kind text=\"\">major</kind>"+
+		"<degree><degree-value>6</degree-value><degree-alter>-1</degree-alter><degree-type>add</degree-type></degree>"+
+		"<degree><degree-value>9</degree-value><degree-alter>0</degree-alter><degree-type>add</degree-type></degree>"+
+		"<degree><degree-value>11</degree-value><degree-alter>0</degree-alter><degree-type>add</degree-type></degree>"+
+		"<degree><degree-value>5</degree-value><degree-alter>-1</degree-alter><degree-type>alter</degree-type></degree>")
+	_, err = (&ChordName{Root: "H"}).BuildMusicXML()
+	assert.ErrorIs(t, err, NameRootError)
+	_, err = (&ChordName{Root: "C", Extended: "17"}).BuildMusicXML()
+	assert.ErrorIs(t, err, NameTokenError)
+
+	chord := &ChordInfo{Pattern: "000220", Fret: 2, Capo: true, Fingering: "---23-"}
+	names, err := chord.GetNames()
+	assert.NoError(t, err)
+	data, err = chord.BuildMusicXML(&names.Base)
+	assert.NoError(t, err)
+	assert.Contains(t, compact.Replace(string(data)), "<frame>"+
+		"<frame-strings>6</frame-strings><frame-frets>6</frame-frets><first-fret>2</first-fret>"+
+		`<frame-note><string>1</string><fret>2</fret><barre type="stop"></barre></frame-note>`+
+		"<frame-note><string>2</string><fret>2</fret></frame-note>"+
+		"<frame-note><string>3</string><fret>2</fret></frame-note>"+
+		"<frame-note><string>4</string><fret>4</fret><fingering>2</fingering></frame-note>"+
+		"<frame-note><string>5</string><fret>4</fret><fingering>3</fingering></frame-note>"+
+		`<frame-note><string>6</string><fret>2</fret><barre type="start"></barre></frame-note>`+
+		"</frame>")
+
+	chord = &ChordInfo{Pattern: "2320XX", Fingering: "231---"}
+	data, err = chord.BuildMusicXML(&ChordName{Root: "D"})
+	assert.NoError(t, err)
+	assert.Contains(t, compact.Replace(string(data)), "<frame>"+
+		"<frame-strings>6</frame-strings><frame-frets>5</frame-frets>"+
+		"<frame-note><string>1</string><fret>2</fret><fingering>2</fingering></frame-note>"+
+		"<frame-note><string>2</string><fret>3</fret><fingering>3</fingering></frame-note>"+
+		"<frame-note><string>3</string><fret>2</fret><fingering>1</fingering></frame-note>"+
+		"<frame-note><string>4</string><fret>0</fret></frame-note>"+
+		"</frame>")
+
+	// open and muted strings in fingering are not fingered
+	chord = &ChordInfo{Pattern: "2320XX", Fingering: "2310XX"}
+	data, err = chord.BuildMusicXML(&ChordName{Root: "D"})
+	assert.NoError(t, err)
+	assert.Contains(t, compact.Replace(string(data)), "<frame-note><string>4</string><fret>0</fret></frame-note></frame>")
+}
+
+func TestLilyPond(t *testing.T) {
+	testCase := []struct {
+		symbol   string
+		duration string
+		expected string
+	}{
+		{symbol: "Cm7(b9)", expected: "c:m7.9-"},
+		{symbol: "E7/G#", duration: "2", expected: "e2:7/gis"},
+		{symbol: "C/D", expected: "c/+d"},
+		{symbol: "Bbmaj7", duration: "4.", expected: "bes4.:maj7"},
+		{symbol: "Ebm7(b5)", expected: "es:m7.5-"},
+		{symbol: "Abdim7", expected: "as:dim7"},
+		{symbol: "Cdim9", expected: "c:dim7.9"},
+		{symbol: "F#7(b9,#11)", expected: "fis:7.9-.11+"},
+		{symbol: "C6/9", expected: "c:6.9"},
+		{symbol: "Cmmaj9", expected: "c:m7+.9"},
+		{symbol: "C7sus4", expected: "c:7sus4"},
+		{symbol: "Csus2(add11)", expected: "c:5.11sus2"},
+		{symbol: "Cm(add9)", expected: "c:m5.9"},
+		{symbol: "Caug", expected: "c:aug"},
+		{symbol: "C5", expected: "c:1.5"},
+		{symbol: "C7no3", expected: "c:7^3"},
+		{symbol: "C", expected: "c"},
+	}
+	for _, r := range testCase {
+		chord, err := ParseName(r.symbol)
+		assert.NoError(t, err, r.symbol)
+		actual, err := chord.Name.BuildLilyPond(r.duration)
+		assert.NoError(t, err, r.symbol)
+		assert.Equal(t, r.expected, actual, r.symbol)
+	}
+	// names from GetNames, flat sixth compounds are split into steps
+	for _, r := range []struct {
+		pattern string
+		fret    int
+		key     Key
+	}{
+		{pattern: "231231", fret: 1, key: Key{Tonic: "Db"}},
+		{pattern: "XX1121"},
+		{pattern: "122331", fret: 5},
+	} {
+		chord := NewChordInfo(r.pattern, r.fret, false)
+		chord.Key = r.key
+		names, err := chord.GetNames()
+		assert.NoError(t, err, r.pattern)
+		for _, name := range append([]ChordName{names.Base, names.BassRooted}, names.Variations...) {
+			_, err := name.BuildLilyPond("")
+			assert.NoError(t, err, name.BuildName())
+		}
+	}
+	actual, err := (&ChordName{Root: "Db", Altered: "b6/9/11,b5"}).BuildLilyPond("")
+	assert.NoError(t, err)
+	assert.Equal(t, "des:5.6-.9.11.5-", actual)
+	_, err = (&ChordName{Root: "C", Altered: "b7"}).BuildLilyPond("")
+	assert.ErrorIs(t, err, NameTokenError)
+
+	for _, r := range []struct {
+		chord    *ChordInfo
+		expected string
+	}{
+		{chord: &ChordInfo{Pattern: "2320XX", Fingering: "231---"}, expected: `\fret-diagram #"w:6;h:5;f:1;1-2-2;2-3-3;3-2-1;4-o;5-x;6-x;"`},
+		{chord: &ChordInfo{Pattern: "112331", Fingering: "112341"}, expected: `\fret-diagram #"w:6;h:5;f:1;c:6-1-1;1-1-1;2-1-1;3-2-2;4-3-3;5-3-4;6-1-1;"`},
+		{chord: &ChordInfo{Pattern: "X2331X", Fret: 6}, expected: `\fret-diagram #"w:6;h:5;2-8;3-9;4-9;5-7;1-x;6-x;"`},
+		{chord: &ChordInfo{Pattern: "000220", Fret: 2, Capo: true}, expected: `\fret-diagram #"w:6;h:6;c:6-1-2;1-2;2-2;3-2;4-4;5-4;6-2;"`},
+	} {
+		actual, err := r.chord.BuildLilyPondDiagram()
+		assert.NoError(t, err, r.chord.Pattern)
+		assert.Equal(t, r.expected, actual, r.chord.Pattern)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	testCase := []struct {
+		text  string
+		chord *ChordInfo
+	}{
+		{text: "x32010@0", chord: &ChordInfo{Pattern: "01023X"}},
+		{text: "x02210@2+capo", chord: &ChordInfo{Pattern: "01220X", Fret: 2, Capo: true}},
+		{text: "x13321@6+capo2", chord: &ChordInfo{Pattern: "12331X", Fret: 6, Capo: true, CapoFret: 2}},
+		{text: "022000@2+capo:2,3,4+span6", chord: &ChordInfo{Pattern: "000220", Fret: 2, Capo: true, CapoStrings: []int{1, 2, 3}, Span: 6}},
+		{text: "000232@0+tuning:D-A-D-G-B-E+fingers:---132", chord: &ChordInfo{Pattern: "232000", Tuning: DropDTuning, Fingering: "231---"}},
+		{text: "x13321@3+key:Db", chord: &ChordInfo{Pattern: "12331X", Fret: 3, Key: Key{Tonic: "Db"}}},
+		{text: "x13321@3+key:C#m", chord: &ChordInfo{Pattern: "12331X", Fret: 3, Key: Key{Tonic: "C#", Minor: true}}},
+	}
+	for _, r := range testCase {
+		text, err := r.chord.MarshalText()
+		assert.NoError(t, err, r.text)
+		assert.Equal(t, r.text, string(text))
+		parsed, err := ParseChordInfo(r.text)
+		assert.NoError(t, err, r.text)
+		assert.Equal(t, r.chord, parsed, r.text)
+
+		data, err := json.Marshal(r.chord)
+		assert.NoError(t, err, r.text)
+		var decoded ChordInfo
+		assert.NoError(t, json.Unmarshal(data, &decoded), r.text)
+		assert.Equal(t, *r.chord, decoded, r.text)
+	}
+	for _, text := range []string{"x32010", "x32010@a", "x32010@0+capo:a", "x32010@0+strings"} {
+		_, err := ParseChordInfo(text)
+		assert.ErrorIs(t, err, ChordTextError, text)
+	}
+	_, err := ParseChordInfo("x37010@0")
+	assert.ErrorIs(t, err, FretPatternError)
+
+	data, err := json.Marshal(&ChordInfo{Pattern: "01023X", Barres: []Barre{{Fret: 1, From: 0, To: 1}}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"version":1,"pattern":"01023X","fret":0,"barres":[{"fret":1,"from":0,"to":1,"full":false}]}`, string(data))
+	var chord ChordInfo
+	assert.NoError(t, json.Unmarshal([]byte(`{"pattern":"01023X","fret":0,"color":"red"}`), &chord))
+	assert.Equal(t, "01023X", chord.Pattern)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"version":2,"pattern":"01023X"}`), &chord), VersionError)
+
+	// capo on no strings is invalid, JSON keeps it apart from full capo
+	chord = ChordInfo{Pattern: "000220", Fret: 2, Capo: true, CapoStrings: []int{}}
+	_, err = chord.MarshalText()
+	assert.ErrorIs(t, err, CapoStringsError)
+	data, err = json.Marshal(&chord)
+	assert.NoError(t, err)
+	var decodedChord ChordInfo
+	assert.NoError(t, json.Unmarshal(data, &decodedChord))
+	assert.Equal(t, chord, decodedChord)
+	_, err = decodedChord.GetNames()
+	assert.ErrorIs(t, err, CapoStringsError)
+
+	chord = ChordInfo{Pattern: "X2302X", Trace: true}
+	names, err := chord.GetNames()
+	assert.NoError(t, err)
+	data, err = json.Marshal(names)
+	assert.NoError(t, err)
+	var decodedNames ChordNames
+	assert.NoError(t, json.Unmarshal(data, &decodedNames))
+	assert.Equal(t, *names, decodedNames)
+
+	// zero name round-trips
+	data, err = json.Marshal(ChordName{})
+	assert.NoError(t, err)
+	name := ChordName{Root: "C"}
+	assert.NoError(t, json.Unmarshal(data, &name))
+	assert.Equal(t, ChordName{}, name)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"name":"Cm7(b9)/G"}`), &name))
+	assert.Equal(t, "Cm7(b9)/G", name.BuildName())
+	assert.Equal(t, []int{0, 1, 3, 7, 10}, name.Intervals)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"version":2,"name":"C"}`), &name), VersionError)
+	text, err := name.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "Cm7(b9)/G", string(text))
+	assert.ErrorIs(t, name.UnmarshalText([]byte("Cx7")), NameTokenError)
+}
+
+func TestTypography(t *testing.T) {
+	german := *StandardStyle
+	german.Locale = GermanLocale
+	testCase := []struct {
+		name    string
+		style   *NameStyle
+		runs    []Run
+		unicode string
+		html    string
+	}{
+		{name: "C#sus4(#5,b9)", runs: []Run{{Text: "C♯"}, {Text: "sus4", Super: true}, {Text: "(♯5,♭9)", Super: true, Stack: []string{"♭9", "♯5"}}},
+			unicode: "C♯sus4(♯5,♭9)", html: `C♯<sup>sus4</sup><sup class="stack">♭9<br>♯5</sup>`},
+		{name: "Bbm7(b5)", runs: []Run{{Text: "B♭m"}, {Text: "7(♭5)", Super: true}},
+			unicode: "B♭m7(♭5)", html: "B♭m<sup>7(♭5)</sup>"},
+		{name: "Bbm7(b5)", style: JazzStyle, runs: []Run{{Text: "B♭ø"}, {Text: "7", Super: true}},
+			unicode: "B♭ø7", html: "B♭ø<sup>7</sup>"},
+		{name: "Ab7(b9,#11)/Gb", style: JazzStyle, runs: []Run{{Text: "A♭"}, {Text: "7", Super: true}, {Text: "♭9♯11", Super: true, Stack: []string{"♯11", "♭9"}}, {Text: "/G♭"}},
+			unicode: "A♭7♭9♯11/G♭", html: `A♭<sup>7</sup><sup class="stack">♯11<br>♭9</sup>/G♭`},
+		{name: "Bbm(addb9)", style: ClassicalStyle, runs: []Run{{Text: "b♭"}, {Text: "(add♭9)", Super: true}},
+			unicode: "b♭(add♭9)", html: "b♭<sup>(add♭9)</sup>"},
+		{name: "Bb7/Eb", style: &german, runs: []Run{{Text: "B"}, {Text: "7", Super: true}, {Text: "/Es"}},
+			unicode: "B7/Es", html: "B<sup>7</sup>/Es"},
+		{name: "C5", runs: []Run{{Text: "C"}, {Text: "5", Super: true}}, unicode: "C5", html: "C<sup>5</sup>"},
+		{name: "C(no3)", runs: []Run{{Text: "C"}, {Text: "no3", Super: true}}, unicode: "Cno3", html: "C<sup>no3</sup>"},
+	}
+	for _, r := range testCase {
+		chord, err := ParseName(r.name)
+		assert.NoError(t, err, r.name)
+		assert.Equal(t, r.runs, chord.Name.BuildRuns(r.style), r.name)
+		assert.Equal(t, r.unicode, chord.Name.BuildUnicode(r.style), r.name)
+		assert.Equal(t, r.html, chord.Name.BuildHTML(r.style), r.name)
+		_, err = (&ChordInfo{Pattern: "01023X"}).BuildPNGRuns(chord.Name.BuildRuns(r.style))
+		assert.NoError(t, err, r.name)
+	}
+}
+
+func TestKey(t *testing.T) {
+	testCase := []struct {
+		frets string
+		key   string
+		name  string
+		tones []string
+	}{
+		{frets: "x-4-6-6-5-4", name: "C#m", tones: []string{"C#", "E", "G#"}},
+		{frets: "x-4-6-6-5-4", key: "Db", name: "Dbm", tones: []string{"Db", "Fb", "Ab"}},
+		{frets: "4-6-6-5-4-4", name: "G#", tones: []string{"G#", "B#", "D#"}},
+		{frets: "4-6-6-5-4-4", key: "E", name: "G#", tones: []string{"G#", "B#", "D#"}},
+		{frets: "4-6-6-5-4-4", key: "C#m", name: "G#", tones: []string{"G#", "B#", "D#"}},
+		{frets: "x-1-3-3-2-1", key: "F", name: "Bbm", tones: []string{"Bb", "Db", "F"}},
+		{frets: "x-x-1-3-2-3", key: "Db", name: "Eb7", tones: []string{"Eb", "G", "Bb", "Db"}},
+		{frets: "3-5-5-4-3-3", key: "E", name: "G", tones: []string{"G", "B", "D"}},
+		{frets: "x-x-6-5-4-4", key: "C", name: "Ab", tones: []string{"Ab", "C", "Eb"}},
+		{frets: "x-x-6-5-4-4", key: "Db", name: "Ab", tones: []string{"Ab", "C", "Eb"}},
+		{frets: "1-3-3-2-1-1", key: "Am", name: "F", tones: []string{"F", "A", "C"}},
+		{frets: "x-x-2-4-3-4", key: "Am", name: "E7", tones: []string{"E", "G#", "B", "D"}},
+	}
+	for _, r := range testCase {
+		chord, err := ParseFrets(r.frets)
+		assert.NoError(t, err, r.frets)
+		if r.key != "" {
+			chord.Key, err = ParseKey(r.key)
+			assert.NoError(t, err, r.key)
+		}
+		actual, err := chord.GetNames()
+		assert.NoError(t, err, r.frets)
+		assert.Equal(t, r.name, actual.Base.BuildName(), r.frets+" in "+r.key)
+		assert.Equal(t, r.tones, actual.Base.Tones, r.frets+" in "+r.key)
+	}
+	for _, r := range []struct {
+		key    string
+		fifths int
+		err    error
+	}{
+		{key: "Db", fifths: -5},
+		{key: "F# minor", fifths: 3},
+		{key: "Ebm", fifths: -6},
+		{key: "C major", fifths: 0},
+		{key: "D#", err: KeyError},
+		{key: "Gbm", err: KeyError},
+		{key: "H", err: KeyError},
+		{key: "C dorian", err: KeyError},
+	} {
+		key, err := ParseKey(r.key)
+		if r.err != nil {
+			assert.ErrorIs(t, err, r.err, r.key)
+			continue
+		}
+		assert.NoError(t, err, r.key)
+		assert.Equal(t, r.fifths, key.Fifths(), r.key)
+		signature, err := KeySignature(r.fifths, key.Minor)
+		assert.NoError(t, err, r.key)
+		assert.Equal(t, key, signature, r.key)
+	}
+	_, err := KeySignature(8, false)
+	assert.ErrorIs(t, err, KeyError)
+	_, err = (&ChordInfo{Pattern: "01023X", Key: Key{Tonic: "Fb"}}).GetNames()
+	assert.ErrorIs(t, err, KeyError)
+}
+
+func TestVoicings(t *testing.T) {
+	testCase := []struct {
+		symbol   string
+		options  *VoicingOptions
+		contains []string
+		excludes []string
+		err      error
+	}{
+		{symbol: "Am7", options: &VoicingOptions{RootInBass: true}, contains: []string{"x02010", "x02013", "575555"}, excludes: []string{"002010", "332010"}},
+		{symbol: "C/E", contains: []string{"032010"}, excludes: []string{"x32010"}},
+		{symbol: "C", options: &VoicingOptions{MaxFret: 5, MinStrings: 5}, contains: []string{"x32010", "332010"}, excludes: []string{"xx2010", "x-3-5-5-5-3"}},
+		{symbol: "D", options: &VoicingOptions{Tuning: DropDTuning, RootInBass: true}, contains: []string{"000232"}},
+		{symbol: "C6", contains: []string{"x35555", "x32210"}, excludes: []string{"x02010", "002010"}},
+		{symbol: "C7", contains: []string{"x32310", "x35353"}},
+		{symbol: "C7", options: &VoicingOptions{NoOptional: true}, contains: []string{"x35353"}, excludes: []string{"x32310"}},
+		{symbol: "C5", contains: []string{"x355xx"}},
+		{symbol: "Bb(b6/9)", contains: []string{"680576", "688778"}},
+		{symbol: "Bb(b6,add9)", contains: []string{"680576", "688778"}},
+		{symbol: "Ebb6/9sus4", contains: []string{"x66466"}},
+		{symbol: "Hm", err: NameRootError},
+		{symbol: "Am", options: &VoicingOptions{Tuning: Tuning{}}, err: TuningEmptyError},
+	}
+	for _, r := range testCase {
+		actual, err := Voicings(r.symbol, r.options)
+		if r.err != nil {
+			assert.ErrorIs(t, err, r.err, r.symbol)
+			continue
+		}
+		assert.NoError(t, err, r.symbol)
+		var frets []string
+		for _, c := range actual {
+			frets = append(frets, c.Frets())
+		}
+		for _, f := range r.contains {
+			assert.Contains(t, frets, f, r.symbol)
+		}
+		for _, f := range r.excludes {
+			assert.NotContains(t, frets, f, r.symbol)
+		}
+	}
+	// every analyzed name has voicings
+	names, err := NewChordInfo("XX1121", 0, false).GetNames()
+	assert.NoError(t, err)
+	for _, name := range append([]ChordName{names.Base}, names.Variations...) {
+		actual, err := name.Voicings(nil)
+		assert.NoError(t, err, name.BuildName())
+		assert.NotEmpty(t, actual, name.BuildName())
+	}
+}
+
+func TestInversions(t *testing.T) {
+	testCase := []struct {
+		pattern    string
+		fret       int
+		name       string
+		bassRooted string
+	}{
+		{pattern: "010230", fret: 0, name: "C/E", bassRooted: "Emb6"},
+		{pattern: "010233", fret: 0, name: "C/G", bassRooted: "G6sus4"},
+		{pattern: "3003XX", fret: 0, name: "G7/F", bassRooted: "Fsus2(b5)"},
+		{pattern: "01023X", fret: 0, name: "C", bassRooted: "C"},
+		{pattern: "1312XX", fret: 0, name: "E7(b9)", bassRooted: "E7(b9)"},
+	}
+	for _, r := range testCase {
+		actual, err := NewChordInfo(r.pattern, r.fret, false).GetNames()
+		assert.NoError(t, err)
+		assert.Equal(t, r.name, actual.Base.BuildName(), r.pattern)
+		assert.Equal(t, r.bassRooted, actual.BassRooted.BuildName(), r.pattern)
+		if actual.Base.Bass != "" {
+			assert.Contains(t, actual.Variations, actual.BassRooted, r.pattern)
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	chord := NewChordInfo("X2302X", 0, false)
+	chord.Trace = true
+	actual, err := chord.GetNames()
+	assert.NoError(t, err)
+	assert.Equal(t, []Step{
+		{Stage: StageQuality, Symbol: "m", Rule: "minor third without major third", Intervals: []int{minThird}},
+		{Stage: StageExtension, Symbol: "maj9", Rule: "major seventh, ninth is the highest extension", Intervals: []int{majSeventh, ninth}},
+		{Stage: StageRoot, Symbol: "B", Rule: "root is spelled with sharps, except Bb and Eb"},
+	}, actual.Base.Steps)
+	assert.Equal(t, "Bmmaj9\nnotes: R 9 b3 7\n"+
+		"quality \"m\": minor third without major third (m3)\n"+
+		"extension \"maj9\": major seventh, ninth is the highest extension (M7, M2)\n"+
+		"root \"B\": root is spelled with sharps, except Bb and Eb\n", actual.Base.Explain())
+
+	chord = NewChordInfo("010230", 0, false)
+	chord.Trace = true
+	actual, err = chord.GetNames()
+	assert.NoError(t, err)
+	last := actual.Base.Steps[len(actual.Base.Steps)-1]
+	assert.Equal(t, StageInversion, last.Stage)
+	assert.Equal(t, "E", last.Symbol)
+
+	chord.Trace = false
+	actual, err = chord.GetNames()
+	assert.NoError(t, err)
+	assert.Nil(t, actual.Base.Steps)
+}
+
+func BenchmarkGetNames(b *testing.B) {
+	chord := NewChordInfo("X2302X", 0, false)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = chord.GetNames()
+	}
+}
+
+func BenchmarkResolve(b *testing.B) {
+	rules := DefaultRules()
+	rules.tableOnce.Do(rules.buildTable)
+	b.Run("table", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			rules.resolve(i%12, uint16(i%2048)<<1|1, Key{}, false)
+		}
+	})
+	b.Run("rules", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			newChordName(rules, i%12, uint16(i%2048)<<1|1, Key{}, false)
+		}
+	})
+}
+
+func BenchmarkBuildTable(b *testing.B) {
+	rules := DefaultRules()
+	for i := 0; i < b.N; i++ {
+		rules.buildTable()
+	}
+}
+
+func TestRules(t *testing.T) {
+	houseStyle := []byte(`
+quality:
+  first:
+    - {symbol: major, has: [M3]}
+    - {symbol: m, has: [m3]}
+    - {symbol: no3}
+extension:
+  first:
+    - {symbol: "7", has: [m7]}
+    - {symbol: none}
+alteration:
+  all:
+    - {symbol: b9, has: [m2], extended: true}
+    - {symbol: addb9, has: [m2], extended: false}
+`)
+	rules, err := LoadRules(fstest.MapFS{"house.yaml": &fstest.MapFile{Data: houseStyle}}, "house.yaml")
+	assert.NoError(t, err)
+	testCase := []struct {
+		pattern  string
+		expected string
+	}{
+		{pattern: "01323X", expected: "C7"},
+		{pattern: "X2X23X", expected: "C(addb9)"},
+		{pattern: "00023X", expected: "C"},
+		{pattern: "XXX53X", expected: "Cno3"},
+	}
+	for _, r := range testCase {
+		chord := NewChordInfo(r.pattern, 0, false)
+		chord.Rules = rules
+		chord.Trace = true
+		actual, err := chord.GetNames()
+		assert.NoError(t, err, r.pattern)
+		assert.Equal(t, r.expected, actual.BassRooted.BuildName(), r.pattern)
+	}
+	chord := NewChordInfo("01323X", 0, false)
+	chord.Rules, chord.Trace = rules, true
+	actual, _ := chord.GetNames()
+	assert.Equal(t, Step{Stage: StageExtension, Symbol: "7", Rule: "has m7", Intervals: []int{minSeventh}}, actual.Base.Steps[1])
+
+	// b6 after flat root is written as extension without as-alteration rule
+	plainB6, err := ParseRules([]byte(strings.Replace(string(defaultRulesYAML), "  as-alteration: true", "  as-alteration: false", 1)))
+	assert.NoError(t, err)
+	for rules, expected := range map[*Rules]string{DefaultRules(): "Bb(b6)", plainB6: "Bbb6"} {
+		chord, _ := ParseFrets("6-8-8-7-7-6")
+		chord.Rules = rules
+		actual, err := chord.GetNames()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual.BassRooted.BuildName())
+	}
+	chord = NewChordInfo("XXX53X", 0, false)
+	actual, _ = chord.GetNames()
+	assert.Equal(t, "C5", actual.Base.BuildName())
+
+	_, err = ParseRules([]byte("quality: {symbol: major, has: [M9]}"))
+	assert.ErrorIs(t, err, RuleIntervalError)
+	_, err = ParseRules([]byte("extension: {first: [{symbol: maj6}]}"))
+	assert.ErrorIs(t, err, RuleSymbolError)
+	_, err = LoadRulesFile("rules.yaml")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,109 @@
+package analyzer
+
+import "sort"
+
+// Barre stores information about one finger pressing several strings on the same fret.
+//
+// Fret is position of barre in Pattern, i.e. the same digit as in pattern.
+// From and To are indexes of the highest and the lowest covered strings, in the same order as Pattern.
+//
+// Full is true when barre covers all the strings.
+type Barre struct {
+	Fret int  `json:"fret"`
+	From int  `json:"from"`
+	To   int  `json:"to"`
+	Full bool `json:"full"`
+}
+
+// FindBarres returns barres of the chord.
+// If Barres field is set, they are validated and returned, otherwise barres are detected from fingering:
+// Fingering field, or suggested one if it is empty. If chord can't be fingered, no barres are returned.
+func (c *ChordInfo) FindBarres() ([]Barre, error) {
+	err := c.validate()
+	if err != nil {
+		return nil, err
+	}
+	return c.barres(true)
+}
+
+// barres returns validated Barres hints or barres of fingering. Fingering is suggested only if suggest is true,
+// because search of fingering is slow
+func (c *ChordInfo) barres(suggest bool) ([]Barre, error) {
+	if c.Barres != nil {
+		res := make([]Barre, len(c.Barres))
+		for i, b := range c.Barres {
+			if b.From > b.To {
+				b.From, b.To = b.To, b.From
+			}
+			if !validBarre(c.Pattern, b) {
+				return nil, invalid(BarreError, b.From, 0, b.Fret)
+			}
+			b.Full = b.From == 0 && b.To == len(c.Pattern)-1
+			res[i] = b
+		}
+		return res, nil
+	}
+	fingering := c.Fingering
+	if fingering == "" && suggest {
+		fingering, _ = c.SuggestFingering()
+	}
+	return detectBarres(c.Pattern, fingering), nil
+}
+
+// detectBarres finds fingers, used on several strings of the same fret
+func detectBarres(pattern, fingering string) []Barre {
+	if fingering == "" {
+		return nil
+	}
+	found := make(map[rune]*Barre)
+	for i, r := range fingering {
+		if r < '1' || r > '4' {
+			continue
+		}
+		fret := int(pattern[i] - 48)
+		if b, ok := found[r]; ok && b.Fret == fret {
+			b.To = i
+		} else if !ok {
+			found[r] = &Barre{Fret: fret, From: i, To: i}
+		}
+	}
+	var res []Barre
+	for _, b := range found {
+		if b.From != b.To {
+			b.Full = b.From == 0 && b.To == len(pattern)-1
+			res = append(res, *b)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Fret == res[j].Fret {
+			return res[i].From < res[j].From
+		}
+		return res[i].Fret < res[j].Fret
+	})
+	return res
+}
+
+func validBarre(pattern string, b Barre) bool {
+	if b.From < 0 || b.To >= len(pattern) || b.From == b.To || b.Fret < 1 {
+		return false
+	}
+	if int(pattern[b.From]-48) != b.Fret || int(pattern[b.To]-48) != b.Fret {
+		return false
+	}
+	for _, r := range pattern[b.From : b.To+1] {
+		if r == '0' || (r != x && int(r-48) < b.Fret) {
+			return false
+		}
+	}
+	return true
+}
+
+// barreAt returns barre, which covers string on the fret
+func barreAt(barres []Barre, str, fret int) (Barre, bool) {
+	for _, b := range barres {
+		if b.Fret == fret && str >= b.From && str <= b.To {
+			return b, true
+		}
+	}
+	return Barre{}, false
+}
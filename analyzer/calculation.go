@@ -1,89 +1,67 @@
 package analyzer
 
-import (
-	"errors"
-)
+import "math/bits"
 
 type nameInfo struct {
 	pattern string
 	fret    int
-	capo    bool
+	capo    []int
+	tuning  []int
 }
 
-func newNameInfo(pattern string, fret int, capo bool) *nameInfo {
+func newNameInfo(pattern string, fret int, capo []int, tuning []int) *nameInfo {
 	return &nameInfo{
 		pattern: pattern,
 		fret:    fret,
 		capo:    capo,
+		tuning:  tuning,
 	}
 }
 
 const (
-	x = 'X'
-
-	eString = 0
-	bString = 7
-	gString = 3
-	dString = 10
-	aString = 5
-)
-
-var (
-	lengthError       = errors.New("invalid request: pattern must consist of six symbols")
-	wrongSymbolsError = errors.New("invalid request: pattern must contain only digits and 'X'")
-	fretNumberError   = errors.New("invalid request: offset fret number must be positive and less or equal '18'")
-	fretPatternError  = errors.New("invalid request: fret number must be less or equal '5'")
+	x        = 'X'
+	fullMask = 1<<12 - 1
 )
 
-func (c *nameInfo) calculateNotes() (map[int][]bool, int, int) {
-	var root, length int
-	var intervals []bool
-	res := make(map[int][]bool)
+// calculateNotes returns set of notes as mask, where bit i is set for note index i, note of the lowest string
+// and number of notes
+func (c *nameInfo) calculateNotes() (uint16, int, int) {
+	var notes uint16
+	root := 0
 	for i, n := range c.pattern {
 		if n != x {
-			note := findNote(i, int(n), c.fret, c.capo)
-			intervals, length = c.getIntervals(note)
-			if _, ok := res[note]; !ok {
-				res[note] = intervals
-			}
-			root = note
+			root = findNote(c.tuning[i], int(n), c.fret, c.capoAt(i))
+			notes |= 1 << root
 		}
 	}
-	return res, root, length
+	return notes, root, bits.OnesCount16(notes)
+}
+
+// intervalMask returns notes as intervals from root: bit i is set when note is i semitones higher than root
+func intervalMask(notes uint16, root int) uint16 {
+	return (notes>>root | notes<<(12-root)) & fullMask
 }
 
-func (c *nameInfo) getIntervals(noteIndex int) ([]bool, int) {
+// getIntervals returns intervals of mask in form used by naming filters
+func getIntervals(mask uint16) []bool {
 	iArr := make([]bool, 12)
-	length := 0
-	for i, n := range c.pattern {
-		if n != x {
-			note := findNote(i, int(n), c.fret, c.capo)
-			ivl := (12 - (noteIndex - note)) % 12
-			if !iArr[ivl] {
-				length++
-				iArr[ivl] = true
-			}
-		}
+	for i := range iArr {
+		iArr[i] = mask&(1<<i) != 0
 	}
-	return iArr, length
+	return iArr
 }
 
-func findNote(str, pos, fret int, capo bool) (res int) {
-	if pos == 48 && fret != 0 && !capo {
-		fret = 0
-	}
-	switch str {
-	case 0, 5:
-		res = (eString + fret + pos - 48) % 12
-	case 1:
-		res = (bString + fret + pos - 48) % 12
-	case 2:
-		res = (gString + fret + pos - 48) % 12
-	case 3:
-		res = (dString + fret + pos - 48) % 12
-	case 4:
-		res = (aString + fret + pos - 48) % 12
+func (c *nameInfo) capoAt(str int) int {
+	if c.capo == nil {
+		return 0
 	}
+	return c.capo[str]
+}
 
-	return
+// findNote returns note index of the string; open strings sound on capo fret
+func findNote(open, pos, fret, capo int) int {
+	if pos == 48 {
+		return (open + capo) % 12
+	}
+	return (open + fret + pos - 48) % 12
 }
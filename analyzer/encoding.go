@@ -0,0 +1,341 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is version of JSON form of ChordInfo and ChordName. It is written to every object
+// and increased when meaning of fields changes. Objects without version are read as version 1.
+const SchemaVersion = 1
+
+// Errors returned by text and JSON decoding
+var (
+	ChordTextError = errors.New("invalid chord text: chord must look like 'x32010@0', optionally followed by " +
+		"'+capo', '+capo2:1,2,3', '+span7', '+tuning:D-A-D-G-B-E', '+fingers:-32-1-' or '+key:Db'")
+	VersionError = errors.New("invalid schema version: data is written by newer version of package")
+)
+
+const (
+	textFret    = "@"
+	textOption  = "+"
+	textValue   = ":"
+	textCapo    = "capo"
+	textSpan    = "span"
+	textTuning  = "tuning"
+	textFingers = "fingers"
+	textKey     = "key"
+)
+
+// chordInfoJSON is JSON form of ChordInfo. CapoStrings is pointer to keep empty list apart from nil
+type chordInfoJSON struct {
+	Version     int     `json:"version"`
+	Pattern     string  `json:"pattern"`
+	Fret        int     `json:"fret"`
+	Span        int     `json:"span,omitempty"`
+	Capo        bool    `json:"capo,omitempty"`
+	CapoFret    int     `json:"capoFret,omitempty"`
+	CapoStrings *[]int  `json:"capoStrings,omitempty"`
+	Tuning      Tuning  `json:"tuning,omitempty"`
+	Fingering   string  `json:"fingering,omitempty"`
+	Barres      []Barre `json:"barres,omitempty"`
+	Trace       bool    `json:"trace,omitempty"`
+	Key         string  `json:"key,omitempty"`
+}
+
+// chordNameJSON is JSON form of ChordName. Name is written for readers and used only when Root is empty
+type chordNameJSON struct {
+	Version   int      `json:"version"`
+	Name      string   `json:"name"`
+	Root      string   `json:"root"`
+	Quality   string   `json:"quality,omitempty"`
+	Extended  string   `json:"extended,omitempty"`
+	Altered   string   `json:"altered,omitempty"`
+	Omitted   string   `json:"omitted,omitempty"`
+	Bass      string   `json:"bass,omitempty"`
+	Score     int      `json:"score,omitempty"`
+	Intervals []int    `json:"intervals,omitempty"`
+	Degrees   []string `json:"degrees,omitempty"`
+	Tones     []string `json:"tones,omitempty"`
+	Steps     []Step   `json:"steps,omitempty"`
+}
+
+// MarshalJSON writes all fields of ChordInfo except Rules and Locale with SchemaVersion
+func (c ChordInfo) MarshalJSON() ([]byte, error) {
+	v := chordInfoJSON{
+		Version:   SchemaVersion,
+		Pattern:   c.Pattern,
+		Fret:      c.Fret,
+		Span:      c.Span,
+		Capo:      c.Capo,
+		CapoFret:  c.CapoFret,
+		Tuning:    c.Tuning,
+		Fingering: c.Fingering,
+		Barres:    c.Barres,
+		Trace:     c.Trace,
+		Key:       c.Key.String(),
+	}
+	if c.CapoStrings != nil {
+		v.CapoStrings = &c.CapoStrings
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON reads ChordInfo written by MarshalJSON. Unknown fields are ignored,
+// data of newer SchemaVersion returns VersionError. Rules and Locale are kept.
+func (c *ChordInfo) UnmarshalJSON(data []byte) error {
+	var v chordInfoJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Version > SchemaVersion {
+		return fmt.Errorf("%w: %d", VersionError, v.Version)
+	}
+	var key Key
+	if v.Key != "" {
+		var err error
+		if key, err = ParseKey(v.Key); err != nil {
+			return err
+		}
+	}
+	c.Pattern, c.Fret, c.Span = v.Pattern, v.Fret, v.Span
+	c.Capo, c.CapoFret, c.CapoStrings = v.Capo, v.CapoFret, nil
+	if v.CapoStrings != nil {
+		c.CapoStrings = *v.CapoStrings
+	}
+	c.Tuning, c.Fingering, c.Barres = v.Tuning, v.Fingering, v.Barres
+	c.Trace, c.Key = v.Trace, key
+	return nil
+}
+
+// MarshalText writes chord in text form, see ParseChordInfo. Barres, Trace, Rules and Locale are not written
+func (c ChordInfo) MarshalText() ([]byte, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	b.WriteString(reverse(strings.ToLower(c.Pattern)))
+	b.WriteString(textFret + strconv.Itoa(c.Fret))
+	if c.Capo {
+		b.WriteString(textOption + textCapo)
+		if c.CapoFret != 0 {
+			b.WriteString(strconv.Itoa(c.CapoFret))
+		}
+		if c.CapoStrings != nil {
+			numbers := make([]string, len(c.CapoStrings))
+			for i, s := range c.CapoStrings {
+				numbers[i] = strconv.Itoa(s + 1)
+			}
+			b.WriteString(textValue + strings.Join(numbers, comma))
+		}
+	}
+	if c.Span != 0 {
+		b.WriteString(textOption + textSpan + strconv.Itoa(c.Span))
+	}
+	if c.Tuning != nil {
+		notes := make([]string, len(c.Tuning))
+		for i, n := range c.Tuning {
+			notes[len(notes)-1-i] = n
+		}
+		b.WriteString(textOption + textTuning + textValue + strings.Join(notes, fretsDivider))
+	}
+	if c.Fingering != "" {
+		b.WriteString(textOption + textFingers + textValue + reverse(c.Fingering))
+	}
+	if c.Key.Tonic != "" {
+		key := c.Key.Tonic
+		if c.Key.Minor {
+			key += "m"
+		}
+		b.WriteString(textOption + textKey + textValue + key)
+	}
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText reads chord in text form, see ParseChordInfo. Rules and Locale are kept
+func (c *ChordInfo) UnmarshalText(text []byte) error {
+	chord, err := ParseChordInfo(string(text))
+	if err != nil {
+		return err
+	}
+	chord.Rules, chord.Locale = c.Rules, c.Locale
+	*c = *chord
+	return nil
+}
+
+// ParseChordInfo parses chord in text form, written by ChordInfo.MarshalText: pattern from the lowest string
+// to the highest with 'x' for muted strings, '@' and Fret, then options:
+//
+// "+capo" sets Capo, "+capo2" also sets CapoFret, "+capo:1,2,3" sets CapoStrings by string numbers from 1
+// for the highest string, ex: "x02210@2+capo", "x24420@0+capo2:1,2,3,4";
+// "+span7" sets Span; "+tuning:D-A-D-G-B-E" sets Tuning from the lowest string; "+fingers:-32-1-" sets Fingering
+// from the lowest string; "+key:Db" or "+key:C#m" sets Key.
+//
+// Result is validated as in GetNames.
+func ParseChordInfo(s string) (*ChordInfo, error) {
+	parts := strings.Split(strings.TrimSpace(s), textOption)
+	head := strings.Split(parts[0], textFret)
+	if len(head) != 2 || head[0] == "" {
+		return nil, fmt.Errorf("%w: %q", ChordTextError, s)
+	}
+	fret, err := strconv.Atoi(head[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ChordTextError, s)
+	}
+	c := &ChordInfo{Pattern: reverse(strings.ToUpper(head[0])), Fret: fret}
+	for _, option := range parts[1:] {
+		name, value, _ := strings.Cut(option, textValue)
+		switch {
+		case strings.HasPrefix(name, textCapo):
+			c.Capo = true
+			if n := strings.TrimPrefix(name, textCapo); n != "" {
+				if c.CapoFret, err = strconv.Atoi(n); err != nil {
+					return nil, fmt.Errorf("%w: %q", ChordTextError, option)
+				}
+			}
+			if value != "" {
+				c.CapoStrings = []int{}
+				for _, f := range strings.Split(value, comma) {
+					n, err := strconv.Atoi(f)
+					if err != nil {
+						return nil, fmt.Errorf("%w: %q", ChordTextError, option)
+					}
+					c.CapoStrings = append(c.CapoStrings, n-1)
+				}
+			}
+		case strings.HasPrefix(name, textSpan):
+			if c.Span, err = strconv.Atoi(strings.TrimPrefix(name, textSpan)); err != nil {
+				return nil, fmt.Errorf("%w: %q", ChordTextError, option)
+			}
+		case name == textTuning:
+			if c.Tuning, err = ParseTuning(value); err != nil {
+				return nil, err
+			}
+		case name == textFingers:
+			c.Fingering = reverse(strings.ToUpper(value))
+		case name == textKey:
+			if c.Key, err = ParseKey(value); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w: %q", ChordTextError, option)
+		}
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// MarshalJSON writes all fields of ChordName, name built by BuildName and SchemaVersion
+func (c ChordName) MarshalJSON() ([]byte, error) {
+	return json.Marshal(chordNameJSON{
+		Version:   SchemaVersion,
+		Name:      c.BuildName(),
+		Root:      c.Root,
+		Quality:   c.Quality,
+		Extended:  c.Extended,
+		Altered:   c.Altered,
+		Omitted:   c.Omitted,
+		Bass:      c.Bass,
+		Score:     c.Score,
+		Intervals: c.Intervals,
+		Degrees:   c.Degrees,
+		Tones:     c.Tones,
+		Steps:     c.Steps,
+	})
+}
+
+// UnmarshalJSON reads ChordName written by MarshalJSON. If root is empty, name is parsed by ParseName,
+// so {"name": "Cm7"} is enough, and empty name is zero ChordName. Data of newer SchemaVersion returns VersionError.
+func (c *ChordName) UnmarshalJSON(data []byte) error {
+	var v chordNameJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Version > SchemaVersion {
+		return fmt.Errorf("%w: %d", VersionError, v.Version)
+	}
+	switch {
+	case v.Root == "" && v.Name == "":
+		*c = ChordName{}
+		return nil
+	case v.Root == "":
+		return c.UnmarshalText([]byte(v.Name))
+	}
+	*c = ChordName{
+		Root:      v.Root,
+		Quality:   v.Quality,
+		Extended:  v.Extended,
+		Altered:   v.Altered,
+		Omitted:   v.Omitted,
+		Bass:      v.Bass,
+		Score:     v.Score,
+		Intervals: v.Intervals,
+		Degrees:   v.Degrees,
+		Tones:     v.Tones,
+		Steps:     v.Steps,
+	}
+	return nil
+}
+
+// MarshalText writes name built by BuildName
+func (c ChordName) MarshalText() ([]byte, error) {
+	return []byte(c.BuildName()), nil
+}
+
+// UnmarshalText reads chord symbol by ParseName
+func (c *ChordName) UnmarshalText(text []byte) error {
+	chord, err := ParseName(string(text))
+	if err != nil {
+		return err
+	}
+	*c = chord.Name
+	return nil
+}
+
+// MarshalText writes key like "Db major", empty for zero Key
+func (k Key) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText reads key by ParseKey, empty text is zero Key
+func (k *Key) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*k = Key{}
+		return nil
+	}
+	key, err := ParseKey(string(text))
+	if err != nil {
+		return err
+	}
+	*k = key
+	return nil
+}
+
+// MarshalText writes name of stage, like "quality"
+func (s Stage) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText reads name of stage
+func (s *Stage) UnmarshalText(text []byte) error {
+	for stage, name := range stageNames {
+		if name == string(text) {
+			*s = stage
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown stage %q", text)
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorKind describes which part of request is invalid
+type ErrorKind int
+
+const (
+	KindEmpty ErrorKind = iota + 1
+	KindLength
+	KindStringNumber
+	KindSymbol
+	KindPatternFret
+	KindFretNumber
+	KindSpan
+	KindCapoFret
+	KindCapoStrings
+	KindTuning
+	KindFingering
+	KindBarre
+	KindName
+	KindFrets
+	KindKey
+)
+
+// Errors returned by validation. Every validation error is *ValidationError, which unwraps to one of them,
+// so they can be checked with errors.Is
+var (
+	EmptyError           = errors.New("invalid request: pattern must contain at list one digit")
+	LengthError          = errors.New("invalid request: pattern must have a symbol for every string of tuning")
+	StringNumberError    = errors.New("invalid request: tuning must have less or equal '12' strings")
+	WrongSymbolsError    = errors.New("invalid request: pattern must contain only digits and 'X'")
+	FretPatternError     = errors.New("invalid request: fret number must be less or equal diagram span")
+	FretNumberError      = errors.New("invalid request: offset fret number must be positive and diagram must end before '23' fret")
+	SpanError            = errors.New("invalid request: diagram span must be from '1' to '9' frets")
+	CapoFretError        = errors.New("invalid request: capo fret must be positive, less or equal '23' and lower than fingered frets under capo")
+	CapoStringsError     = errors.New("invalid request: capo strings must be unique indexes of pattern strings, at least one")
+	TuningNoteError      = errors.New("invalid tuning: notes must be letters from 'A' to 'G' with optional '#' or 'b'")
+	TuningEmptyError     = errors.New("invalid tuning: tuning must contain at least one note")
+	FingeringLengthError = errors.New("invalid fingering: fingering must have a symbol for every string of pattern")
+	FingeringSymbolError = errors.New("invalid fingering: fingering must contain only digits from 1 to 4, 'T' and '-'")
+	FingeringMatchError  = errors.New("invalid fingering: every fingered string must have a finger and open or muted strings must not")
+	BarreError           = errors.New("invalid barre: barre must cover at least two fingered strings on its fret without open strings or lower frets under it")
+	NameError            = errors.New("invalid name: chord name must not be empty or longer than 20 symbols")
+	FretsSymbolError     = errors.New("invalid frets: frets must be numbers or 'x' for muted strings")
+	FretsSpanError       = errors.New("invalid frets: fingered frets must fit in nine frets window")
+	FretsRangeError      = errors.New("invalid frets: fret number must be less or equal '23'")
+	KeyError             = errors.New("invalid key: key must have tonic and up to 7 sharps or flats, like 'Db' or 'F#m'")
+)
+
+// FingeringError is returned by SuggestFingering for valid shapes, which can't be played with one hand
+var FingeringError = errors.New("chord can't be fingered: pattern needs more than four fingers or too wide stretch")
+
+var kinds = map[error]ErrorKind{
+	EmptyError:           KindEmpty,
+	LengthError:          KindLength,
+	StringNumberError:    KindStringNumber,
+	WrongSymbolsError:    KindSymbol,
+	FretPatternError:     KindPatternFret,
+	FretNumberError:      KindFretNumber,
+	SpanError:            KindSpan,
+	CapoFretError:        KindCapoFret,
+	CapoStringsError:     KindCapoStrings,
+	TuningNoteError:      KindTuning,
+	TuningEmptyError:     KindTuning,
+	FingeringLengthError: KindFingering,
+	FingeringSymbolError: KindFingering,
+	FingeringMatchError:  KindFingering,
+	BarreError:           KindBarre,
+	NameError:            KindName,
+	FretsSymbolError:     KindFrets,
+	FretsSpanError:       KindFrets,
+	FretsRangeError:      KindFrets,
+	KeyError:             KindKey,
+}
+
+// ValidationError stores details of invalid request.
+//
+// Index is index of the offending string in the same order as Pattern, or -1 if error is not related to one string.
+// For CapoStringsError it is index of the offending entry of CapoStrings.
+// Rune is the offending symbol of Pattern, Fingering or Tuning note, or 0.
+// Limit is the bound, which was exceeded, like diagram span for FretPatternError, or 0.
+type ValidationError struct {
+	Kind  ErrorKind
+	Index int
+	Rune  rune
+	Limit int
+	err   error
+}
+
+func invalid(err error, index int, r rune, limit int) *ValidationError {
+	return &ValidationError{
+		Kind:  kinds[err],
+		Index: index,
+		Rune:  r,
+		Limit: limit,
+		err:   err,
+	}
+}
+
+func (e *ValidationError) Error() string {
+	msg := e.err.Error()
+	switch {
+	case e.Index >= 0 && e.Kind == KindCapoStrings:
+		msg += fmt.Sprintf(": capo strings entry %d", e.Index)
+	case e.Index >= 0:
+		msg += fmt.Sprintf(": string %d", e.Index)
+	}
+	if e.Rune != 0 {
+		msg += fmt.Sprintf(": symbol '%c'", e.Rune)
+	}
+	if e.Limit != 0 {
+		msg += fmt.Sprintf(": limit %d", e.Limit)
+	}
+	return msg
+}
+
+// Unwrap returns one of the validation errors, like EmptyError
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
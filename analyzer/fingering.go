@@ -0,0 +1,203 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	thumb      = 'T'
+	noFinger   = '-'
+	maxFingers = 4
+
+	barreCost = 3
+	thumbCost = 3
+)
+
+type fingeredNote struct {
+	str  int
+	fret int
+}
+
+type fingerInfo struct {
+	pattern string
+	notes   []fingeredNote
+	fingers []int
+	best    []int
+	cost    int
+}
+
+func newFingerInfo(pattern string) *fingerInfo {
+	var notes []fingeredNote
+	for i, r := range pattern {
+		if r != x && r != '0' {
+			notes = append(notes, fingeredNote{str: i, fret: int(r - 48)})
+		}
+	}
+	// one finger per fret, thicker strings first
+	sort.Slice(notes, func(i, j int) bool {
+		if notes[i].fret == notes[j].fret {
+			return notes[i].str > notes[j].str
+		}
+		return notes[i].fret < notes[j].fret
+	})
+	return &fingerInfo{
+		pattern: pattern,
+		notes:   notes,
+		fingers: make([]int, len(notes)),
+		cost:    -1,
+	}
+}
+
+// SuggestFingering returns fingering for the chord in the same order as Pattern:
+// digits from 1 (index) to 4 (little finger), 'T' for thumb and '-' for open and muted strings.
+// Ex: "01023X" (C major) --> "-1-23-".
+//
+// Fingers follow frets, so lower finger can't stand on higher fret, and the same finger can press several strings
+// on the same fret only as barre, with no open strings under it. Thumb can be used only for the lowest fingered string.
+// From all possible fingerings the one with the least stretch, barres and thumb usage is returned.
+//
+// Result can be set to Fingering field to render it in tab and PNG.
+// Invalid request returns *ValidationError, and shape, which can't be fingered, returns FingeringError.
+func (c *ChordInfo) SuggestFingering() (string, error) {
+	err := c.validate()
+	if err != nil {
+		return "", err
+	}
+	info := newFingerInfo(c.Pattern)
+	info.suggest(0, 0)
+	if len(info.notes) > 0 {
+		last := info.lowest()
+		rest := info.without(last)
+		if rest.thumbAllowed(info.notes[last]) {
+			rest.suggest(0, thumbCost)
+			if rest.cost >= 0 && (info.cost < 0 || rest.cost < info.cost) {
+				return rest.fingering(info.notes[last]), nil
+			}
+		}
+	}
+	if info.cost < 0 {
+		return "", FingeringError
+	}
+	return info.fingering(fingeredNote{str: -1}), nil
+}
+
+// suggest checks all the fingerings with non-decreasing fingers and keeps the cheapest one
+func (f *fingerInfo) suggest(i, cost int) {
+	if f.cost >= 0 && cost >= f.cost {
+		return
+	}
+	if i == len(f.notes) {
+		f.cost = cost
+		f.best = append(f.best[:0], f.fingers...)
+		return
+	}
+	from := 1
+	if i > 0 {
+		from = f.fingers[i-1]
+	}
+	for finger := from; finger <= maxFingers; finger++ {
+		add, ok := f.place(i, finger)
+		if ok {
+			f.fingers[i] = finger
+			f.suggest(i+1, cost+add)
+		}
+	}
+}
+
+// place returns cost of putting finger on i-th note and false if it is impossible
+func (f *fingerInfo) place(i, finger int) (int, bool) {
+	note := f.notes[i]
+	if i == 0 {
+		return finger - 1, true
+	}
+	prev, prevFinger := f.notes[i-1], f.fingers[i-1]
+	if finger == prevFinger {
+		if note.fret != prev.fret || !f.barreAllowed(note.str, prev.str, note.fret) {
+			return 0, false
+		}
+		return barreCost, true
+	}
+	if note.fret-prev.fret > finger-prevFinger+1 {
+		return 0, false
+	}
+	// distance from "one finger per fret" position
+	first, firstFinger := f.notes[0], f.fingers[0]
+	shift := (note.fret - first.fret) - (finger - firstFinger)
+	if shift < 0 {
+		shift = -shift
+	}
+	return shift, true
+}
+
+func (f *fingerInfo) barreAllowed(from, to, fret int) bool {
+	if from > to {
+		from, to = to, from
+	}
+	for _, r := range f.pattern[from : to+1] {
+		if r == '0' || (r != x && int(r-48) < fret) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fingerInfo) without(i int) *fingerInfo {
+	notes := append(f.notes[:i:i], f.notes[i+1:]...)
+	return &fingerInfo{
+		pattern: f.pattern,
+		notes:   notes,
+		fingers: make([]int, len(notes)),
+		cost:    -1,
+	}
+}
+
+// lowest returns index of note on the lowest fingered string
+func (f *fingerInfo) lowest() int {
+	last := 0
+	for i, n := range f.notes {
+		if n.str > f.notes[last].str {
+			last = i
+		}
+	}
+	return last
+}
+
+func (f *fingerInfo) thumbAllowed(note fingeredNote) bool {
+	for _, n := range f.notes {
+		if n.fret < note.fret-1 {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *fingerInfo) fingering(thumbNote fingeredNote) string {
+	res := []byte(strings.Repeat(string(noFinger), len(f.pattern)))
+	for i, n := range f.notes {
+		res[n.str] = byte('0' + f.best[i])
+	}
+	if thumbNote.str >= 0 {
+		res[thumbNote.str] = thumb
+	}
+	return string(res)
+}
+
+func validateFingering(pattern, fingering string) error {
+	if fingering == "" {
+		return nil
+	}
+	if len(fingering) != len(pattern) {
+		return invalid(FingeringLengthError, -1, 0, len(pattern))
+	}
+	for i, r := range fingering {
+		fingered := r == thumb || (r >= '1' && r <= '4')
+		if !fingered && r != noFinger && r != '0' && r != x {
+			return invalid(FingeringSymbolError, i, r, 0)
+		}
+		if fingered != (pattern[i] != x && pattern[i] != '0') {
+			return invalid(FingeringMatchError, i, r, 0)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package analyzer
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	fretsMuted   = "x"
+	fretsDivider = "-"
+)
+
+// ParseFrets converts absolute fret notation into ChordInfo with the best diagram window.
+// Frets are written from the lowest string to the highest, as in chord books:
+// "x32010", "x-10-12-12-11-x" or "8 10 10 9 8 8". Frets over 9 must be separated with spaces, dashes or commas.
+//
+// Fret of returned ChordInfo is 0, if all frets fit in the first five frets. Otherwise the lowest fingered fret
+// is placed on the first fret of the diagram, and the diagram is moved down, if it would end after the last fret.
+// Open strings stay open and Capo is false. Wider shapes get Span up to 9 frets.
+// Tuning is not set, so set it for instruments with other number of strings.
+//
+// Invalid frets return *ValidationError, which unwraps to FretsSymbolError, FretsRangeError or FretsSpanError,
+// or to EmptyError for empty string.
+func ParseFrets(s string) (*ChordInfo, error) {
+	var fields []string
+	if strings.ContainsAny(s, " ,-") {
+		fields = strings.FieldsFunc(s, func(r rune) bool {
+			return r == ' ' || r == ',' || r == '-'
+		})
+	} else {
+		fields = strings.Split(s, "")
+	}
+	if len(fields) == 0 {
+		return nil, invalid(EmptyError, -1, 0, 0)
+	}
+	frets := make([]int, len(fields))
+	for i, f := range fields {
+		if strings.EqualFold(f, fretsMuted) {
+			frets[i] = -1
+			continue
+		}
+		// index of the string in pattern order, from the highest string
+		index := len(fields) - 1 - i
+		fret, err := strconv.Atoi(f)
+		if err != nil || fret < 0 {
+			return nil, invalid(FretsSymbolError, index, []rune(f)[0], 0)
+		}
+		if fret > boardFrets {
+			return nil, invalid(FretsRangeError, index, 0, boardFrets)
+		}
+		frets[i] = fret
+	}
+	return fromFrets(frets)
+}
+
+// fromFrets converts absolute frets from the lowest string to the highest, -1 for muted, into ChordInfo
+func fromFrets(frets []int) (*ChordInfo, error) {
+	low, high := 0, 0
+	for _, fret := range frets {
+		if fret > 0 && (low == 0 || fret < low) {
+			low = fret
+		}
+		if fret > high {
+			high = fret
+		}
+	}
+	offset := 0
+	if high > diagramSpan {
+		offset = low - 1
+	}
+	span := 0
+	if high-offset > maxSpan {
+		return nil, invalid(FretsSpanError, -1, 0, maxSpan)
+	}
+	if high-offset > diagramSpan {
+		span = high - offset
+	}
+	// diagram must end on the last fret of the board
+	window := diagramSpan
+	if span != 0 {
+		window = span
+	}
+	if offset+window > boardFrets {
+		offset = boardFrets - window
+	}
+	pattern := make([]byte, len(frets))
+	for i, fret := range frets {
+		p := &pattern[len(frets)-1-i]
+		switch fret {
+		case -1:
+			*p = x
+		case 0:
+			*p = '0'
+		default:
+			*p = byte('0' + fret - offset)
+		}
+	}
+	chord := NewChordInfo(string(pattern), offset, false)
+	chord.Span = span
+	return chord, nil
+}
+
+// Frets returns absolute fret notation of the chord from the lowest string to the highest, like "x32010".
+// If any fret is over 9, frets are separated with dashes: "x-10-12-12-11-x".
+//
+// Open strings under capo are written with capo fret number.
+func (c *ChordInfo) Frets() string {
+	frets := make([]string, len(c.Pattern))
+	wide := false
+	capo := c.covered()
+	for i, r := range c.Pattern {
+		var fret string
+		switch {
+		case r == x:
+			fret = fretsMuted
+		case r == '0' && !underCapo(capo, i):
+			fret = "0"
+		case r == '0':
+			wide = wide || capo[i] > 9
+			fret = strconv.Itoa(capo[i])
+		default:
+			abs := c.Fret + int(r-48)
+			wide = wide || abs > 9
+			fret = strconv.Itoa(abs)
+		}
+		frets[len(c.Pattern)-1-i] = fret
+	}
+	if wide {
+		return strings.Join(frets, fretsDivider)
+	}
+	return strings.Join(frets, "")
+}
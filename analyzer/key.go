@@ -0,0 +1,119 @@
+package analyzer
+
+import "strings"
+
+// Key is tonality used to spell chord roots, ex: Key{Tonic: "Db"} or Key{Tonic: "F#", Minor: true}.
+// Tonic must be one of keys with up to 7 sharps or flats.
+//
+// Zero Key means that no key is given, then roots are spelled by chord quality.
+type Key struct {
+	Tonic string
+	Minor bool
+}
+
+// tonics of keys in order of circle of fifths, from 7 flats to 7 sharps
+var (
+	majorTonics = []string{"Cb", "Gb", "Db", "Ab", "Eb", "Bb", "F", "C", "G", "D", "A", "E", "B", "F#", "C#"}
+	minorTonics = []string{"Ab", "Eb", "Bb", "F", "C", "G", "D", "A", "E", "B", "F#", "C#", "G#", "D#", "A#"}
+)
+
+// keyDegrees are degrees of notes from tonic. Notes out of scale are lowered 2nd, 3rd, 6th and 7th
+// and raised 4th, as they are borrowed from parallel key or used as leading tones.
+var keyDegrees = []string{"R", "b2", "2", "b3", "3", "4", "#4", "5", "b6", "6", "b7", "7"}
+
+var (
+	majorScale = []int{0, 2, 4, 5, 7, 9, 11}
+	minorScale = []int{0, 2, 3, 5, 7, 8, 10}
+)
+
+// ParseKey parses key like "Db", "C#m", "Bb minor" or "E major"
+func ParseKey(s string) (Key, error) {
+	s = strings.TrimSpace(s)
+	tonic, n := readNote(s)
+	if n == 0 {
+		return Key{}, KeyError
+	}
+	var k Key
+	switch strings.TrimSpace(s[n:]) {
+	case "", "maj", "major":
+		k = Key{Tonic: tonic}
+	case "m", "min", "minor":
+		k = Key{Tonic: tonic, Minor: true}
+	default:
+		return Key{}, KeyError
+	}
+	if !k.valid() {
+		return Key{}, KeyError
+	}
+	return k, nil
+}
+
+// KeySignature returns key with number of fifths in signature: positive for sharps, negative for flats
+func KeySignature(fifths int, minor bool) (Key, error) {
+	if fifths < -7 || fifths > 7 {
+		return Key{}, KeyError
+	}
+	if minor {
+		return Key{Tonic: minorTonics[fifths+7], Minor: true}, nil
+	}
+	return Key{Tonic: majorTonics[fifths+7]}, nil
+}
+
+// Fifths returns number of sharps (positive) or flats (negative) in key signature
+func (k Key) Fifths() int {
+	return indexOf(k.tonics(), k.Tonic) - 7
+}
+
+func (k Key) tonics() []string {
+	if k.Minor {
+		return minorTonics
+	}
+	return majorTonics
+}
+
+func (k Key) valid() bool {
+	return indexOf(k.tonics(), k.Tonic) >= 0
+}
+
+// String returns key like "Db major" or "F# minor"
+func (k Key) String() string {
+	if k.Tonic == "" {
+		return ""
+	}
+	if k.Minor {
+		return k.Tonic + " minor"
+	}
+	return k.Tonic + " major"
+}
+
+func (k Key) validate() error {
+	if k.Tonic != "" && !k.valid() {
+		return invalid(KeyError, -1, 0, 0)
+	}
+	return nil
+}
+
+// root returns name of note index in the key. Notes of scale are spelled as in key signature,
+// other notes are spelled by keyDegrees. If it gives double accidentals or Cb, Fb, E# and B#,
+// the note is spelled with flats in flat keys and with sharps in other keys.
+func (k Key) root(note int) string {
+	tonic, _ := parseNote(k.Tonic)
+	iv := (note - tonic + 12) % 12
+	name := spell(k.Tonic, iv, keyDegrees[iv])
+	scale := majorScale
+	if k.Minor {
+		scale = minorScale
+	}
+	for _, s := range scale {
+		if s == iv {
+			return name
+		}
+	}
+	if len(name) == 1 || len(name) == 2 && !strings.Contains("Cb Fb E# B#", name) {
+		return name
+	}
+	if k.Fifths() < 0 {
+		return nameSymbols.major[nameSymbols.notes[note]]
+	}
+	return nameSymbols.sharp[nameSymbols.notes[note]]
+}
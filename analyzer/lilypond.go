@@ -0,0 +1,207 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lilyExtensions are LilyPond steps of extension symbols. The first step builds the chord, the others are added,
+// ex: maj9 of minor chord is "7+.9", b13 of diminished chord is "7.13-"
+var lilyExtensions = map[string][]string{
+	"6": {"6"}, "b6": {"6-"}, "7": {"7"}, "9": {"9"}, "11": {"11"}, "13": {"13"},
+	"maj7": {"maj7"}, "maj9": {"maj9"}, "maj11": {"maj11"}, "maj13": {"maj13"},
+}
+
+var (
+	lilyMinorExtensions = map[string][]string{
+		"maj7": {"7+"}, "maj9": {"7+", "9"}, "maj11": {"7+", "9", "11"}, "maj13": {"7+", "9", "13"},
+	}
+	lilyDimExtensions = map[string][]string{
+		"7": {"7"}, "9": {"7", "9"}, "11": {"7", "11"}, "b13": {"7", "13-"},
+	}
+)
+
+// lilyAdded are LilyPond steps of extensions, which are added to the first one, like 9 in 6/9
+var lilyAdded = map[string]string{
+	"6": "6", "b6": "6-", "7": "7", "9": "9", "11": "11", "13": "13",
+	"maj7": "7+", "maj9": "7+", "maj11": "7+", "maj13": "7+",
+}
+
+// lilyAlterations are LilyPond steps of alteration symbols
+var lilyAlterations = map[string]string{
+	"b5": "5-", "#5": "5+", "b6": "6-", "b9": "9-", "#9": "9+", "#11": "11+", "b13": "13-",
+	"addb9": "9-", "add9": "9", "add#9": "9+", "add11": "11", "add#11": "11+",
+}
+
+// BuildLilyPond returns chord in LilyPond \chordmode syntax with optional duration, ex: "c:m7.9-" for Cm7(b9)
+// or "e2:7/gis" for E7/G# with duration "2". Notes are written in default Dutch names: "cis", "bes", "es".
+func (c *ChordName) BuildLilyPond(duration string) (string, error) {
+	root, err := lilyNote(c.Root)
+	if err != nil {
+		return "", &ParseError{Symbol: c.Root, err: NameRootError}
+	}
+	var steps []string
+	var ext []string
+	if c.Extended != "" {
+		ext = strings.FieldsFunc(c.Extended, func(r rune) bool {
+			return r == ',' || r == '/'
+		})
+	}
+	var quality, sus string
+	switch c.Quality {
+	case "sus2", "sus4":
+		sus = c.Quality
+	default:
+		quality = c.Quality
+	}
+	for i, e := range ext {
+		var s []string
+		var ok bool
+		switch {
+		case i > 0:
+			var add string
+			add, ok = lilyAdded[e]
+			s = []string{add}
+		case e == "5":
+			s, ok = []string{"1", "5"}, true
+		case c.Quality == "m" && strings.HasPrefix(e, "maj"):
+			s, ok = lilyMinorExtensions[e]
+		case c.Quality == "dim":
+			s, ok = lilyDimExtensions[e]
+			if !ok && e == "b6" {
+				s, ok = []string{"5", "6-"}, true
+			}
+		default:
+			s, ok = lilyExtensions[e]
+		}
+		if !ok {
+			return "", &ParseError{Symbol: c.Extended, err: NameTokenError}
+		}
+		steps = append(steps, s...)
+	}
+	if c.Altered != "" {
+		if len(steps) == 0 {
+			steps = append(steps, "5")
+		}
+		for _, a := range splitAlterations(c.Altered) {
+			s, ok := lilyAlterations[a]
+			if !ok {
+				return "", &ParseError{Symbol: c.Altered, err: NameTokenError}
+			}
+			steps = append(steps, s)
+		}
+	}
+	if len(c.Intervals) == 1 {
+		steps = []string{"1"}
+	}
+	body := quality + strings.Join(steps, ".") + sus
+	if c.Omitted != "" {
+		body += "^3"
+	}
+	res := root + duration
+	if body != "" {
+		res += ":" + body
+	}
+	if c.Bass != "" {
+		bass, err := lilyNote(c.Bass)
+		if err != nil {
+			return "", &ParseError{Symbol: c.Bass, err: NameBassError}
+		}
+		// bass, which is not chord tone, is added with "/+"
+		res += "/"
+		if !c.hasNote(c.Bass) {
+			res += "+"
+		}
+		res += bass
+	}
+	return res, nil
+}
+
+// hasNote reports whether note is one of chord tones
+func (c *ChordName) hasNote(note string) bool {
+	n, err := parseNote(note)
+	if err != nil {
+		return false
+	}
+	root, err := parseNote(c.Root)
+	if err != nil {
+		return false
+	}
+	for _, iv := range c.Intervals {
+		if (root+iv)%12 == n {
+			return true
+		}
+	}
+	return false
+}
+
+// lilyNote returns note in LilyPond Dutch names, ex: "fis" for "F#", "bes" for "Bb", "as" for "Ab"
+func lilyNote(n string) (string, error) {
+	if _, err := parseNote(n); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(strings.ToLower(n[:1]))
+	for _, a := range n[1:] {
+		if a == '#' {
+			b.WriteString("is")
+		} else {
+			b.WriteString("es")
+		}
+	}
+	res := b.String()
+	if strings.HasPrefix(res, "ees") || strings.HasPrefix(res, "aes") {
+		res = res[:1] + res[2:]
+	}
+	return res, nil
+}
+
+// BuildLilyPondDiagram returns LilyPond \fret-diagram markup of the chord with absolute frets, fingering and barres,
+// ex: `\fret-diagram #"w:6;h:5;f:1;1-2-2;2-3-3;3-2-1;4-o;5-x;6-x;"` for D chord.
+// Open strings under capo are shown on capo fret and connected with barre, as in BuildMusicXML.
+func (c *ChordInfo) BuildLilyPondDiagram() (string, error) {
+	if err := c.validate(); err != nil {
+		return "", err
+	}
+	frame, err := c.frame()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "w:%d;h:%d;", frame.Strings, frame.Frets)
+	if c.Fingering != "" {
+		b.WriteString("f:1;")
+	}
+	// barre stops on the highest string, which goes first, and starts on the lowest one
+	stops := make(map[int]int)
+	for _, n := range frame.Notes {
+		switch {
+		case n.Barre == nil:
+		case n.Barre.Type == "stop":
+			stops[n.Fret] = n.String
+		default:
+			fmt.Fprintf(&b, "c:%d-%d-%d;", n.String, stops[n.Fret], n.Fret)
+		}
+	}
+	sounding := make(map[int]bool)
+	for _, n := range frame.Notes {
+		sounding[n.String] = true
+		b.WriteString(strconv.Itoa(n.String))
+		if n.Fret == 0 {
+			b.WriteString("-o;")
+			continue
+		}
+		fmt.Fprintf(&b, "-%d", n.Fret)
+		if n.Fingering != "" {
+			b.WriteString("-" + n.Fingering)
+		}
+		b.WriteString(";")
+	}
+	for s := 1; s <= frame.Strings; s++ {
+		if !sounding[s] {
+			fmt.Fprintf(&b, "%d-x;", s)
+		}
+	}
+	return `\fret-diagram #"` + b.String() + `"`, nil
+}
@@ -0,0 +1,95 @@
+package analyzer
+
+import "strings"
+
+// Locale names notes in language of chord charts. Chords are analyzed and parsed in English letter notation,
+// Locale only changes output: chord names, when it is set in NameStyle, and string labels of tab and PNG,
+// when it is set in ChordInfo.
+//
+// Notes replace whole notes, ex: "B" -> "H" and "Bb" -> "B" in German. Other notes are built from Letters
+// and accidentals: Letters replace note letters, ex: "C" -> "Do", Sharp and Flat replace '#' and 'b',
+// ex: "is" and "es" for "Fis" and "Des". Letters without replacement and empty accidentals are written as is.
+//
+// Triads replace quality of chords without extensions, alterations and omissions, ex: "m" -> "-Moll"
+// and "" -> "-Dur" for "Fis-Moll" and "C-Dur".
+type Locale struct {
+	Notes   map[string]string
+	Letters map[string]string
+	Sharp   string
+	Flat    string
+	Triads  map[string]string
+}
+
+// Built-in locales
+var (
+	// GermanLocale uses H for B, B for Bb and "-is", "-es" suffixes, triads are "-Dur" and "-Moll":
+	// "Fis-Moll", "Es-Dur", "B", "H", "Cism7"
+	GermanLocale = &Locale{
+		Notes: map[string]string{
+			"B": "H", "B#": "His", "B##": "Hisis", "Bb": "B", "Bbb": "Heses",
+			"Eb": "Es", "Ebb": "Eses", "Ab": "As", "Abb": "Asas",
+		},
+		Sharp:  "is",
+		Flat:   "es",
+		Triads: map[string]string{"": "-Dur", "m": "-Moll"},
+	}
+	// SolfegeLocale uses Italian fixed do, triads are "maggiore" and "minore": "Do maggiore", "Re# minore", "Solm7"
+	SolfegeLocale = &Locale{
+		Letters: map[string]string{"C": "Do", "D": "Re", "E": "Mi", "F": "Fa", "G": "Sol", "A": "La", "B": "Si"},
+		Triads:  map[string]string{"": " maggiore", "m": " minore"},
+	}
+	// FrenchLocale uses French fixed do, triads are "majeur" and "mineur": "Do majeur", "Ré# mineur", "Solm7"
+	FrenchLocale = &Locale{
+		Letters: map[string]string{"C": "Do", "D": "Ré", "E": "Mi", "F": "Fa", "G": "Sol", "A": "La", "B": "Si"},
+		Triads:  map[string]string{"": " majeur", "m": " mineur"},
+	}
+)
+
+// Note returns name of letter note in the locale, ex: "Fis" for "F#" in GermanLocale.
+// Use it for notes of ChordNames.Strings and Tones. Nil Locale keeps letter notation.
+func (l *Locale) Note(n string) string {
+	if l == nil || n == "" {
+		return n
+	}
+	if r, ok := l.Notes[n]; ok {
+		return r
+	}
+	var b strings.Builder
+	if r, ok := l.Letters[n[:1]]; ok {
+		b.WriteString(r)
+	} else {
+		b.WriteString(n[:1])
+	}
+	for _, a := range n[1:] {
+		switch {
+		case a == '#' && l.Sharp != "":
+			b.WriteString(l.Sharp)
+		case a == 'b' && l.Flat != "":
+			b.WriteString(l.Flat)
+		default:
+			b.WriteRune(a)
+		}
+	}
+	return b.String()
+}
+
+// notes returns names of notes in the locale
+func (l *Locale) notes(list []string) []string {
+	if l == nil {
+		return list
+	}
+	res := make([]string, len(list))
+	for i, n := range list {
+		res[i] = l.Note(n)
+	}
+	return res
+}
+
+// triad returns quality of plain triad in the locale and whether it is replaced
+func (l *Locale) triad(c *ChordName) (string, bool) {
+	if l == nil || c.Extended != "" || c.Altered != "" || c.Omitted != "" || len(c.Intervals) == 1 {
+		return "", false
+	}
+	r, ok := l.Triads[c.Quality]
+	return r, ok
+}
@@ -0,0 +1,47 @@
+package analyzer
+
+// buildTable resolves names of all interval sets on all roots. Interval sets always have the root,
+// so they are indexed by mask>>1.
+func (r *Rules) buildTable() {
+	r.table = new([12][1 << 11]ChordName)
+	for root := range r.table {
+		for i := range r.table[root] {
+			r.table[root][i] = newChordName(r, root, uint16(i)<<1|1, Key{}, false)
+		}
+	}
+}
+
+// resolve returns name of intervals mask on root note index. Names are taken from table, which is built once
+// on the first lookup, except traced ones, which are calculated every time to record steps,
+// and ones with root spelled differently in key. Score is not set.
+func (r *Rules) resolve(root int, mask uint16, key Key, trace bool) ChordName {
+	if trace {
+		return newChordName(r, root, mask, key, true)
+	}
+	r.tableOnce.Do(r.buildTable)
+	name := r.table[root][mask>>1]
+	if key.Tonic != "" && key.root(root) != name.Root {
+		return newChordName(r, root, mask, key, false)
+	}
+	name.Intervals = append([]int(nil), name.Intervals...)
+	name.Degrees = append([]string(nil), name.Degrees...)
+	name.Tones = append([]string(nil), name.Tones...)
+	return name
+}
+
+// newChordName calculates name of intervals mask on root note index
+func newChordName(rules *Rules, root int, mask uint16, key Key, trace bool) ChordName {
+	rootName, quality, extended, altered, omitted, steps := getNames(rules, root, mask, key, trace)
+	name := ChordName{
+		Root:     rootName,
+		Quality:  quality,
+		Extended: extended,
+		Altered:  altered,
+		Omitted:  omitted,
+		Steps:    steps,
+	}
+	name.Intervals = intervalSet(getIntervals(mask))
+	name.Degrees = degrees(name.Intervals, name)
+	name.Tones = tones(name)
+	return name
+}
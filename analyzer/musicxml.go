@@ -0,0 +1,354 @@
+package analyzer
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// xmlHarmony is MusicXML <harmony> element
+type xmlHarmony struct {
+	XMLName xml.Name    `xml:"harmony"`
+	Root    xmlRoot     `xml:"root"`
+	Kind    xmlKind     `xml:"kind"`
+	Bass    *xmlBass    `xml:"bass"`
+	Degrees []xmlDegree `xml:"degree"`
+	Frame   *xmlFrame   `xml:"frame"`
+}
+
+type xmlRoot struct {
+	Step  string `xml:"root-step"`
+	Alter int    `xml:"root-alter,omitempty"`
+}
+
+type xmlBass struct {
+	Step  string `xml:"bass-step"`
+	Alter int    `xml:"bass-alter,omitempty"`
+}
+
+type xmlKind struct {
+	Text  string `xml:"text,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDegree struct {
+	Value int    `xml:"degree-value"`
+	Alter int    `xml:"degree-alter"`
+	Type  string `xml:"degree-type"`
+}
+
+type xmlFrame struct {
+	Strings   int            `xml:"frame-strings"`
+	Frets     int            `xml:"frame-frets"`
+	FirstFret int            `xml:"first-fret,omitempty"`
+	Notes     []xmlFrameNote `xml:"frame-note"`
+}
+
+type xmlFrameNote struct {
+	String    int       `xml:"string"`
+	Fret      int       `xml:"fret"`
+	Fingering string    `xml:"fingering,omitempty"`
+	Barre     *xmlBarre `xml:"barre"`
+}
+
+type xmlBarre struct {
+	Type string `xml:"type,attr"`
+}
+
+// harmonyDegree is chord tone added, altered or subtracted from MusicXML kind
+type harmonyDegree struct {
+	value int
+	alter int
+}
+
+// xmlKinds are MusicXML kinds of quality and extension, with degrees implied by kind
+var xmlKinds = map[string]xmlKindInfo{
+	"|":      {"major", []int{3, 5}},
+	"|7":     {"dominant", []int{3, 5, 7}},
+	"|9":     {"dominant-ninth", []int{3, 5, 7, 9}},
+	"|11":    {"dominant-11th", []int{3, 5, 7, 9, 11}},
+	"|13":    {"dominant-13th", []int{3, 5, 7, 9, 11, 13}},
+	"|maj7":  {"major-seventh", []int{3, 5, 7}},
+	"|maj9":  {"major-ninth", []int{3, 5, 7, 9}},
+	"|maj11": {"major-11th", []int{3, 5, 7, 9, 11}},
+	"|maj13": {"major-13th", []int{3, 5, 7, 9, 11, 13}},
+	"|6":     {"major-sixth", []int{3, 5, 6}},
+	"|5":     {"power", []int{5}},
+	"m|":     {"minor", []int{3, 5}},
+	"m|7":    {"minor-seventh", []int{3, 5, 7}},
+	"m|9":    {"minor-ninth", []int{3, 5, 7, 9}},
+	"m|11":   {"minor-11th", []int{3, 5, 7, 9, 11}},
+	"m|13":   {"minor-13th", []int{3, 5, 7, 9, 11, 13}},
+	"m|maj7": {"major-minor", []int{3, 5, 7}},
+	"m|6":    {"minor-sixth", []int{3, 5, 6}},
+	"m|7|b5": {"half-diminished", []int{3, 5, 7}},
+	"dim|":   {"diminished", []int{3, 5}},
+	"dim|7":  {"diminished-seventh", []int{3, 5, 7}},
+	"aug|":   {"augmented", []int{3, 5}},
+	"sus2|":  {"suspended-second", []int{2, 5}},
+	"sus4|":  {"suspended-fourth", []int{4, 5}},
+}
+
+type xmlKindInfo struct {
+	kind    string
+	implied []int
+}
+
+// seventhExtensions are seventh chords, which extensions are built on. Diminished chords have b13 on diminished seventh
+var seventhExtensions = map[string]string{
+	"9": "7", "11": "7", "13": "7", "b13": "7", "maj9": "maj7", "maj11": "maj7", "maj13": "maj7",
+}
+
+// extensionDegrees are degrees of extension tokens, which are not implied by kind
+var extensionDegrees = map[string][]harmonyDegree{
+	"5":     nil,
+	"6":     {{6, 0}},
+	"b6":    {{6, -1}},
+	"b13":   {{13, -1}},
+	"7":     {{7, -1}},
+	"9":     {{7, -1}, {9, 0}},
+	"11":    {{7, -1}, {9, 0}, {11, 0}},
+	"13":    {{7, -1}, {9, 0}, {13, 0}},
+	"maj7":  {{7, 0}},
+	"maj9":  {{7, 0}, {9, 0}},
+	"maj11": {{7, 0}, {9, 0}, {11, 0}},
+	"maj13": {{7, 0}, {9, 0}, {13, 0}},
+}
+
+// alterationDegrees are degrees of alteration symbols
+var alterationDegrees = map[string]harmonyDegree{
+	"b5": {5, -1}, "#5": {5, 1}, "b6": {6, -1}, "b9": {9, -1}, "#9": {9, 1}, "#11": {11, 1}, "b13": {13, -1},
+	"addb9": {9, -1}, "add9": {9, 0}, "add#9": {9, 1}, "add11": {11, 0}, "add#11": {11, 1},
+}
+
+// BuildMusicXML returns MusicXML <harmony> element of the chord name: root, kind, bass and degrees,
+// which are added, altered or subtracted from kind, ex: C7(b9) is dominant kind with added flat ninth.
+func (c *ChordName) BuildMusicXML() ([]byte, error) {
+	h, err := newHarmony(c)
+	if err != nil {
+		return nil, err
+	}
+	return xml.MarshalIndent(h, "", "  ")
+}
+
+// BuildMusicXML returns MusicXML <harmony> element of the chord name with <frame> fret diagram of the chord.
+// Frets, fingering and barres are taken from the chord, open strings under capo are shown on capo fret with barre.
+func (c *ChordInfo) BuildMusicXML(name *ChordName) ([]byte, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	h, err := newHarmony(name)
+	if err != nil {
+		return nil, err
+	}
+	if h.Frame, err = c.frame(); err != nil {
+		return nil, err
+	}
+	return xml.MarshalIndent(h, "", "  ")
+}
+
+func newHarmony(c *ChordName) (*xmlHarmony, error) {
+	step, alter, err := noteStep(c.Root)
+	if err != nil {
+		return nil, &ParseError{Symbol: c.Root, err: NameRootError}
+	}
+	h := &xmlHarmony{Root: xmlRoot{Step: step, Alter: alter}}
+	if c.Bass != "" {
+		step, alter, err := noteStep(c.Bass)
+		if err != nil {
+			return nil, &ParseError{Symbol: c.Bass, err: NameBassError}
+		}
+		h.Bass = &xmlBass{Step: step, Alter: alter}
+	}
+	var ext []string
+	if c.Extended != "" {
+		ext = strings.FieldsFunc(c.Extended, func(r rune) bool {
+			return r == ',' || r == '/'
+		})
+	}
+	alt := splitAlterations(c.Altered)
+	main := ""
+	if len(ext) > 0 {
+		main = ext[0]
+	}
+	kind, degrees, alt, ok := harmonyKind(c.Quality, main, alt)
+	if !ok {
+		return nil, &ParseError{Symbol: c.Quality + c.Extended, err: NameTokenError}
+	}
+	h.Kind = xmlKind{Text: kindText(c), Value: kind.kind}
+	if kind.kind == "half-diminished" {
+		h.Kind.Text += "b5"
+	}
+	implied := make(map[int]bool)
+	for _, d := range kind.implied {
+		implied[d] = true
+	}
+	for _, d := range degrees {
+		implied[d.value] = true
+		h.Degrees = append(h.Degrees, xmlDegree{Value: d.value, Alter: d.alter, Type: "add"})
+	}
+	// other extensions add only their own degree, like 9 in 6/9 or major seventh in 9,maj9
+	for i := 1; i < len(ext); i++ {
+		ds, known := extensionDegrees[ext[i]]
+		if !known {
+			return nil, &ParseError{Symbol: c.Extended, err: NameTokenError}
+		}
+		if len(ds) == 0 {
+			continue
+		}
+		d := ds[len(ds)-1]
+		if strings.HasPrefix(ext[i], "maj") {
+			d = ds[0]
+		}
+		implied[d.value] = true
+		h.Degrees = append(h.Degrees, xmlDegree{Value: d.value, Alter: d.alter, Type: "add"})
+	}
+	for _, a := range alt {
+		d, known := alterationDegrees[a]
+		if !known {
+			return nil, &ParseError{Symbol: c.Altered, err: NameTokenError}
+		}
+		typ := "add"
+		if implied[d.value] && !strings.HasPrefix(a, "add") {
+			typ = "alter"
+		}
+		h.Degrees = append(h.Degrees, xmlDegree{Value: d.value, Alter: d.alter, Type: typ})
+	}
+	if c.Omitted != "" {
+		h.Degrees = append(h.Degrees, xmlDegree{Value: 3, Type: "subtract"})
+	}
+	return h, nil
+}
+
+// harmonyKind returns kind of quality and main extension and degrees of extension, which are not implied by kind.
+// Extensions without own kind are added to their seventh chord or to triad. Flat fifth of half-diminished chord
+// is removed from alterations.
+func harmonyKind(quality, ext string, alt []string) (xmlKindInfo, []harmonyDegree, []string, bool) {
+	degrees, known := extensionDegrees[ext]
+	if ext != "" && !known {
+		return xmlKindInfo{}, nil, alt, false
+	}
+	seventh := ext
+	if s, ok := seventhExtensions[ext]; ok {
+		seventh = s
+	}
+	if quality == "m" && seventh == "7" && len(alt) > 0 && alt[0] == "b5" {
+		return xmlKinds["m|7|b5"], withoutSeventh(degrees), alt[1:], true
+	}
+	if kind, ok := xmlKinds[quality+"|"+ext]; ok {
+		return kind, nil, alt, true
+	}
+	if kind, ok := xmlKinds[quality+"|"+seventh]; ok && seventh != ext {
+		return kind, withoutSeventh(degrees), alt, true
+	}
+	kind, ok := xmlKinds[quality+"|"]
+	return kind, degrees, alt, ok
+}
+
+func withoutSeventh(degrees []harmonyDegree) []harmonyDegree {
+	var res []harmonyDegree
+	for _, d := range degrees {
+		if d.value != 7 {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+// kindText returns quality and extension as they are written in BuildName
+func kindText(c *ChordName) string {
+	if c.Quality == "sus2" || c.Quality == "sus4" {
+		return c.Extended + c.Quality
+	}
+	return c.Quality + c.Extended
+}
+
+// splitAlterations returns alteration symbols of chord name. Flat sixth compounds are split into their degrees,
+// ex: "b6", "add9", "add11", "b5" for "b6/9/11,b5"
+func splitAlterations(altered string) []string {
+	if altered == "" {
+		return nil
+	}
+	var res []string
+	for _, a := range strings.Split(altered, comma) {
+		parts := strings.Split(a, slash)
+		res = append(res, parts[0])
+		for _, p := range parts[1:] {
+			sym := p
+			for _, t := range addTokens {
+				if t.token == p {
+					sym = t.symbol
+				}
+			}
+			res = append(res, sym)
+		}
+	}
+	return res
+}
+
+// noteStep returns letter and number of semitones of accidentals of note, ex: "B", -1 for "Bb"
+func noteStep(n string) (string, int, error) {
+	if _, err := parseNote(n); err != nil {
+		return "", 0, err
+	}
+	return strings.ToUpper(n[:1]), strings.Count(n, "#") - strings.Count(n, "b"), nil
+}
+
+// frame returns fret diagram of the chord. Strings are numbered from the highest, as in Pattern
+func (c *ChordInfo) frame() (*xmlFrame, error) {
+	barres, err := c.barres(true)
+	if err != nil {
+		return nil, err
+	}
+	capo := c.covered()
+	first, last := c.Fret+1, c.Fret+c.span()
+	if fret := c.capoFret(); fret > 0 && fret < first {
+		first = fret
+	}
+	f := &xmlFrame{Strings: len(c.Pattern), Frets: last - first + 1}
+	if first > 1 {
+		f.FirstFret = first
+	}
+	starts, stops := make(map[int]bool), make(map[int]bool)
+	for _, b := range barres {
+		starts[b.To], stops[b.From] = true, true
+	}
+	capoFrom, capoTo := -1, -1
+	for i, r := range c.Pattern {
+		if r == x {
+			continue
+		}
+		note := xmlFrameNote{String: i + 1}
+		switch {
+		case r != '0':
+			note.Fret = c.Fret + int(r-'0')
+		case capo != nil && capo[i] > 0:
+			note.Fret = capo[i]
+			if capoFrom < 0 {
+				capoFrom = len(f.Notes)
+			}
+			capoTo = len(f.Notes)
+		}
+		if c.Fingering != "" {
+			switch fg := c.Fingering[i]; fg {
+			case noFinger, '0', x:
+			case 'T':
+				note.Fingering = "T"
+			default:
+				note.Fingering = strconv.Itoa(int(fg - '0'))
+			}
+		}
+		switch {
+		case starts[i]:
+			note.Barre = &xmlBarre{Type: "start"}
+		case stops[i]:
+			note.Barre = &xmlBarre{Type: "stop"}
+		}
+		f.Notes = append(f.Notes, note)
+	}
+	if capoFrom >= 0 && capoFrom != capoTo {
+		f.Notes[capoTo].Barre = &xmlBarre{Type: "start"}
+		f.Notes[capoFrom].Barre = &xmlBarre{Type: "stop"}
+	}
+	return f, nil
+}
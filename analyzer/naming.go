@@ -3,9 +3,13 @@ package analyzer
 import "strings"
 
 type filters struct {
-	q []bool
-	e []bool
-	a []bool
+	q     []bool
+	e     []bool
+	a     []bool
+	trace *trace
+	// flatRoot is true for root spelled with flat, asAlteration writes extensions as alteration
+	flatRoot     bool
+	asAlteration bool
 }
 type symbols struct {
 	q     []string
@@ -24,6 +28,10 @@ func install() *filters {
 		a: []bool{false, false, false, false, false, false, false, false, false, false, false, false},
 	}
 }
+
+// nameSymbols are shared by all naming calls, they are never changed
+var nameSymbols = initSymbols()
+
 func initSymbols() *symbols {
 	return &symbols{
 		q:     []string{"sus2", "sus4", "m", "dim", "", "aug", "no3"},
@@ -105,37 +113,36 @@ const (
 	slash = "/"
 )
 
-func (f *filters) do(c []bool) {
-	f.qualityFilter(c)
-	f.extensionFilter(c)
-	f.alterFilter(c)
-}
-
-func getNames(rootIndex int, intervals []bool, length int) (root, quality, extended, altered, omitted string) {
+// getNames returns symbols of chord with root on rootIndex and intervals mask, named by rules.
+// Root is spelled in key, if it is given. Decisions are recorded in steps, if trace is true
+func getNames(rules *Rules, rootIndex int, intervals uint16, key Key, trace bool) (root, quality, extended, altered, omitted string, steps []Step) {
 	filter := install()
-	sym := initSymbols()
+	sym := nameSymbols
 	defer func() {
 		filter = nil
 		sym = nil
 	}()
-	root = sym.notes[rootIndex]
-	if length == 1 {
-		root = sym.minor[root]
-		return
+	if trace {
+		filter.trace = newTrace(sym)
+		defer func() {
+			steps = filter.trace.steps
+		}()
 	}
-	switch {
-	case filter.q[qdur]:
-		root = sym.major[root]
-	case filter.q[qdim], filter.q[qaug]:
-		root = sym.sharp[root]
-	default:
-		root = sym.minor[root]
+	root = sym.minor[sym.notes[rootIndex]]
+	if key.Tonic != "" {
+		root = key.root(rootIndex)
 	}
-	if filter.powerFilter(intervals, length) {
-		extended = sym.e[e5]
+	if intervals == 1 {
+		filter.trace.add(StageNotes, root, "single note is not a chord")
 		return
 	}
-	filter.do(intervals)
+	rootRule := "root is spelled with sharps, except Bb and Eb"
+	if key.Tonic != "" {
+		rootRule = "root is spelled in key of " + key.String()
+	}
+	filter.flatRoot = strings.ContainsRune(root, 'b')
+	rules.do(filter, intervals)
+	filter.trace.add(StageRoot, root, rootRule)
 	var ext, alt []string
 	if filter.q[ono3] {
 		omitted = sym.q[ono3]
@@ -148,7 +155,7 @@ func getNames(rootIndex int, intervals []bool, length int) (root, quality, exten
 		}
 	}
 	if !filter.e[onoe] {
-		for i := 1; i < onoe; i++ {
+		for i := 0; i < onoe; i++ {
 			if filter.e[i] {
 				ext = append(ext, sym.e[i])
 			}
@@ -160,7 +167,7 @@ func getNames(rootIndex int, intervals []bool, length int) (root, quality, exten
 		}
 	}
 	extended = strings.Join(ext, filter.separator())
-	if strings.ContainsRune(root, 'b') && quality == "" && filter.e[eb6] {
+	if filter.asAlteration {
 		altered = extended
 		extended = ""
 	}
@@ -171,211 +178,6 @@ func getNames(rootIndex int, intervals []bool, length int) (root, quality, exten
 	return
 }
 
-func (f *filters) powerFilter(c []bool, length int) bool {
-	f.e[e5] = c[perfectFifth] && length == 2
-	return f.e[e5]
-}
-
-func (f *filters) qualityFilter(c []bool) {
-	var q int
-	switch {
-	case c[majThird]:
-		q = qdur
-	case c[minThird]:
-		q = qmin
-	case c[perfectFourth]:
-		q = qsus4
-	case c[majSecond]:
-		q = qsus2
-	default:
-		q = ono3
-	}
-	if !c[perfectFifth] && !c[minSeventh] && !c[majSeventh] {
-		if q == qdur && c[sharpFifth] && !c[flatFifth] { //!c[flatFifth]
-			q = qaug
-		}
-		if q == qmin && c[flatFifth] && !c[sharpFifth] { //!c[sharpFifth]
-			q = qdim
-		}
-	}
-	f.qSelect(q)
-}
-
-func (f *filters) extensionFilter(c []bool) {
-	switch {
-	case f.q[qdim]:
-		if c[sixth] {
-			switch {
-			case c[flatThirteenth]:
-				f.eSelect(eb13)
-			case c[eleventh]:
-				f.eSelect(e11)
-			case c[ninth]:
-				f.eSelect(e9)
-			default:
-				f.eSelect(e7)
-			}
-		} else if c[flatSixth] {
-			f.eSelect(eb6)
-			f.e[e9] = c[ninth]
-			f.e[e11] = c[eleventh]
-		} else {
-			f.eSelect(onoe)
-		}
-	case f.q[qaug]:
-		if c[sixth] {
-			f.eSelect(e6)
-			f.e[e9] = c[ninth]
-			f.e[e11] = c[eleventh]
-		} else {
-			f.eSelect(onoe)
-		}
-
-	case f.q[qsus2], f.q[qsus4], f.q[qmin], f.q[qdur], f.q[ono3]:
-		if c[minSeventh] || c[majSeventh] {
-			rise := 0
-			switch {
-			case c[thirteenth]:
-				rise = e13
-			case c[eleventh] && !f.q[qsus4]:
-				rise = e11
-			case c[ninth] && !f.q[qsus2]:
-				rise = e9
-			default:
-				rise = e7
-			}
-			if c[minSeventh] {
-				f.eSelect(rise)
-			}
-			if c[majSeventh] {
-				f.eSelect(rise + 4)
-			}
-		} else if c[flatSixth] || c[sixth] {
-			if c[sixth] {
-				f.eSelect(e6)
-			} else {
-				f.eSelect(eb6)
-			}
-			f.e[e9] = c[ninth] && !f.q[qsus2]
-			f.e[e11] = c[eleventh] && !f.q[qsus4]
-		} else {
-			f.eSelect(onoe)
-		}
-	}
-}
-func (f *filters) alterFilter(c []bool) {
-	switch {
-	case f.q[qdur], f.q[qsus4], f.q[qsus2]:
-		if f.e[onoe] {
-			f.a[addb9] = c[flatNinth]
-			f.a[add9] = c[ninth] && !f.q[qsus2]
-			f.a[adds9] = c[sharpNinth] && f.q[qdur]
-			f.a[add11] = c[eleventh] && !f.q[qsus4]
-			if c[perfectFifth] {
-				f.a[adds11] = c[sharpEleventh]
-				f.a[ab6] = c[flatSixth]
-			} else {
-				f.a[ab5] = c[flatFifth]
-				f.a[as5] = c[sharpFifth]
-			}
-		} else {
-			f.a[ab9] = c[flatNinth]
-			f.a[as9] = c[sharpNinth]
-			if c[perfectFifth] {
-				f.a[as11] = c[sharpEleventh]
-				if c[flatSixth] && !f.e[eb6] {
-					if (f.e[e13] || f.e[emaj13]) && !f.e[e6] {
-						f.aSelect(ab6)
-					} else {
-						f.aSelect(ab13)
-					}
-				}
-			} else {
-				f.a[ab5] = c[flatFifth]
-				f.a[as5] = c[sharpFifth] && !f.e[eb6]
-			}
-		}
-	case f.q[qmin]:
-		if f.e[onoe] {
-			f.a[addb9] = c[flatNinth]
-			f.a[add9] = c[ninth]
-			f.a[add11] = c[eleventh]
-			if c[perfectFifth] {
-				f.a[adds11] = c[sharpEleventh]
-				f.a[ab6] = c[flatSixth]
-			} else {
-				f.a[ab5] = c[flatFifth]
-				f.a[as5] = c[sharpFifth]
-			}
-		} else {
-			f.a[ab9] = c[flatNinth]
-			if c[flatFifth] {
-				if c[perfectFifth] {
-					f.aSelect(as11)
-				} else {
-					f.aSelect(ab5)
-					f.a[as5] = c[sharpFifth] //&& !f.e[EB6]
-				}
-			} else if c[flatSixth] && !f.e[eb6] {
-				if (f.e[e13] || f.e[emaj13]) && !f.e[e6] {
-					f.aSelect(ab6)
-				} else {
-					f.aSelect(ab13)
-				}
-			}
-		}
-	case f.q[qdim]:
-		if f.e[onoe] {
-			f.a[addb9] = c[flatNinth]
-			f.a[add9] = c[ninth]
-			f.a[add11] = c[eleventh]
-		} else {
-			f.a[ab9] = c[flatNinth]
-		}
-	case f.q[qaug]:
-		if f.e[onoe] {
-			f.a[addb9] = c[flatNinth]
-			f.a[add9] = c[ninth]
-			f.a[adds9] = c[sharpNinth]
-			f.a[add11] = c[eleventh]
-			f.a[adds11] = c[sharpEleventh]
-		} else {
-			f.a[ab9] = c[flatNinth]
-			f.a[as9] = c[sharpNinth]
-		}
-	case f.q[ono3]:
-		if f.e[onoe] {
-			f.a[addb9] = c[flatNinth]
-			if c[perfectFifth] {
-				f.a[adds11] = c[sharpEleventh]
-				f.a[ab6] = c[flatSixth]
-			} else {
-				f.a[ab5] = c[flatFifth]
-				f.a[as5] = c[sharpFifth]
-			}
-		} else {
-			f.a[ab9] = c[flatNinth]
-			f.a[as11] = c[sharpEleventh]
-			if c[flatSixth] && !f.e[eb6] {
-				if (f.e[e13] || f.e[emaj13]) && !f.e[e6] {
-					f.aSelect(ab6)
-				} else {
-					f.aSelect(ab13)
-				}
-			}
-		}
-	}
-}
-
-func (f *filters) qSelect(i int) {
-	f.q[i] = true
-}
-func (f *filters) eSelect(i int) {
-	f.e[i] = true
-}
-func (f *filters) aSelect(i int) {
-	f.a[i] = true
-}
 func (f *filters) separator() string {
 	if f.e[e6] || f.e[eb6] {
 		return slash
@@ -0,0 +1,136 @@
+package analyzer
+
+import "strings"
+
+// StringNote stores information about the sound of one string.
+//
+// String is index of the string in the same order as Pattern.
+// Fret is absolute fret number, 0 for open string, capo fret for open string under capo and -1 for muted string.
+// PitchClass is octave-independent note number, from 0 for C to 11 for B.
+// Note is spelled as tone of base chord.
+// Degree is label of interval from the root of base chord: "R", "b3", "5", "b7", "9", etc.
+//
+// Note, PitchClass and Degree are empty for muted strings, PitchClass is -1.
+type StringNote struct {
+	String     int    `json:"string"`
+	Muted      bool   `json:"muted"`
+	Fret       int    `json:"fret"`
+	Note       string `json:"note"`
+	PitchClass int    `json:"pitchClass"`
+	Degree     string `json:"degree"`
+}
+
+// pitchC is note index of C. Note indexes are counted from E, pitch classes are counted from C
+const pitchC = 8
+
+func pitchClass(note int) int {
+	return (note + 12 - pitchC) % 12
+}
+
+// intervalSet returns semitones of intervals from root, ex: [0 4 7] for major triad
+func intervalSet(intervals []bool) []int {
+	var res []int
+	for i, ok := range intervals {
+		if ok {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// degrees returns labels of intervals in context of chord name
+func degrees(intervals []int, name ChordName) []string {
+	res := make([]string, len(intervals))
+	for i, iv := range intervals {
+		res[i] = degree(iv, name)
+	}
+	return res
+}
+
+// degree returns label of interval in context of chord name: minor third is "b3" in minor chord,
+// but "#9" in dominant chord with major third.
+func degree(iv int, name ChordName) string {
+	has := func(s, sub string) bool {
+		return strings.Contains(s, sub)
+	}
+	switch iv {
+	case 0:
+		return "R"
+	case flatNinth:
+		return "b9"
+	case ninth:
+		if name.Quality == "sus2" {
+			return "2"
+		}
+		return "9"
+	case minThird:
+		if name.Quality == "m" || name.Quality == "dim" {
+			return "b3"
+		}
+		return "#9"
+	case majThird:
+		return "3"
+	case perfectFourth:
+		if name.Quality == "sus4" {
+			return "4"
+		}
+		return "11"
+	case flatFifth:
+		if name.Quality == "dim" || has(name.Altered, "b5") {
+			return "b5"
+		}
+		return "#11"
+	case perfectFifth:
+		return "5"
+	case flatSixth:
+		switch {
+		case name.Quality == "aug" || has(name.Altered, "#5"):
+			return "#5"
+		case has(name.Extended, "b6") || has(name.Altered, "b6"):
+			return "b6"
+		}
+		return "b13"
+	case sixth:
+		switch {
+		case name.Quality == "dim" && !has(name.Extended, "b6"):
+			return "bb7"
+		case has(name.Extended, "6"):
+			return "6"
+		}
+		return "13"
+	case minSeventh:
+		return "b7"
+	case majSeventh:
+		return "7"
+	}
+	return ""
+}
+
+// stringNotes returns notes of all strings, spelled as tones of base chord, with degrees from its root
+func (c *nameInfo) stringNotes(root int, base ChordName) []StringNote {
+	spelled := make(map[int]string)
+	for i, iv := range base.Intervals {
+		spelled[iv] = base.Tones[i]
+	}
+	res := make([]StringNote, len(c.pattern))
+	for i, r := range c.pattern {
+		if r == x {
+			res[i] = StringNote{String: i, Muted: true, Fret: -1, PitchClass: -1}
+			continue
+		}
+		note := findNote(c.tuning[i], int(r), c.fret, c.capoAt(i))
+		iv := (note - root + 12) % 12
+		fret := c.capoAt(i)
+		if r != '0' {
+			fret = c.fret + int(r-48)
+		}
+		res[i] = StringNote{
+			String:     i,
+			Fret:       fret,
+			Note:       spelled[iv],
+			PitchClass: pitchClass(note),
+			Degree:     degree(iv, base),
+		}
+	}
+	return res
+}
@@ -0,0 +1,390 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ParsedChord stores result of ParseName.
+//
+// Name has all the fields filled, including Intervals, Degrees, Tones and Bass of slash chord.
+// Optional stores intervals, which are implied by the name but can be omitted, like the 9th in C11,
+// and unaltered fifth, which is also in Name.Intervals.
+// PitchClasses stores all chord notes including bass, from 0 for C to 11 for B.
+type ParsedChord struct {
+	Name         ChordName
+	Optional     []int
+	PitchClasses []int
+}
+
+// ParseError stores position of the unparsable part of chord symbol
+type ParseError struct {
+	Symbol string
+	Pos    int
+	err    error
+}
+
+// Errors returned by ParseName inside *ParseError
+var (
+	NameRootError  = errors.New("invalid chord symbol: symbol must start with note from 'A' to 'G'")
+	NameTokenError = errors.New("invalid chord symbol: unknown token")
+	NameBassError  = errors.New("invalid chord symbol: bass must be note from 'A' to 'G'")
+)
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %q at position %d", e.err.Error(), e.Symbol[e.Pos:], e.Pos)
+}
+
+// Unwrap returns one of the parse errors, like NameTokenError
+func (e *ParseError) Unwrap() error {
+	return e.err
+}
+
+// token maps variant of chord symbol part to the symbol used by ChordName
+type token struct {
+	token  string
+	symbol string
+}
+
+// qualityTokens maps quality variants to symbols of ChordName, the longest first
+var qualityTokens = []token{
+	{"sus2", "sus2"}, {"sus4", "sus4"}, {"sus", "sus4"},
+	{"dim", "dim"}, {"aug", "aug"}, {"min", "m"}, {"mi", "m"},
+	{"m", "m"}, {"-", "m"}, {"°", "dim"}, {"o", "dim"}, {"+", "aug"},
+}
+
+// extensionTokens maps extension variants to symbols of ChordName, the longest first
+var extensionTokens = []token{
+	{"maj13", "maj13"}, {"maj11", "maj11"}, {"maj9", "maj9"}, {"maj7", "maj7"},
+	{"ma13", "maj13"}, {"ma11", "maj11"}, {"ma9", "maj9"}, {"ma7", "maj7"},
+	{"Maj13", "maj13"}, {"Maj11", "maj11"}, {"Maj9", "maj9"}, {"Maj7", "maj7"},
+	{"M13", "maj13"}, {"M11", "maj11"}, {"M9", "maj9"}, {"M7", "maj7"},
+	{"Δ13", "maj13"}, {"Δ11", "maj11"}, {"Δ9", "maj9"}, {"Δ7", "maj7"}, {"Δ", "maj7"},
+	{"b13", "b13"}, {"13", "13"}, {"11", "11"}, {"b6", "b6"},
+	{"9", "9"}, {"7", "7"}, {"6", "6"}, {"5", "5"},
+}
+
+// alterationTokens maps alteration variants to symbols of ChordName, the longest first
+var alterationTokens = []token{
+	{"add#11", "add#11"}, {"add11", "add11"}, {"add#9", "add#9"}, {"addb9", "addb9"}, {"add9", "add9"},
+	{"add2", "add9"}, {"add4", "add11"},
+	{"#11", "#11"}, {"b13", "b13"}, {"b5", "b5"}, {"#5", "#5"}, {"b6", "b6"}, {"b9", "b9"}, {"#9", "#9"},
+}
+
+// addTokens are extensions without seventh, which can be written in parentheses, like "Cm(maj7)" or "Bb(b6/9)"
+var addTokens = []token{
+	{"maj7", "maj7"}, {"11", "add11"}, {"9", "add9"},
+}
+
+// ParseName parses chord symbol into ChordName and the set of intervals.
+// It understands names built by BuildName, like "C#sus4(#5,b9)", "Cm7(b9)/G", "F#7#9", "Bbmaj13#11", "Db(b6/9,b5)" or "Eno3",
+// and common variants: "min", "-", "M7", "Maj7", "Δ", "ø", "°", "+", "sus", "add2".
+// Lower case root is root of minor or diminished chord, as in ClassicalStyle: "c", "f#7", "c°7".
+//
+// Errors are *ParseError with position of the unparsable part.
+func ParseName(symbol string) (*ParsedChord, error) {
+	body := strings.TrimSpace(symbol)
+	res := &ParsedChord{}
+	// lower case root is minor or diminished chord, as in ClassicalStyle
+	lowerRoot := body != "" && strings.ContainsRune(strings.ToLower(naturals), rune(body[0]))
+	head := body
+	if lowerRoot {
+		head = strings.ToUpper(body[:1]) + body[1:]
+	}
+	root, n := readNote(head)
+	if n == 0 {
+		return nil, &ParseError{Symbol: symbol, Pos: 0, err: NameRootError}
+	}
+	res.Name.Root = root
+	if i := bassSlash(body); i >= 0 {
+		bass, n := readNote(body[i+1:])
+		if n == 0 || i+1+n != len(body) {
+			return nil, &ParseError{Symbol: symbol, Pos: i + 1, err: NameBassError}
+		}
+		res.Name.Bass = bass
+		body = body[:i]
+	}
+	var ext, alt, sixthAlt []string
+	var sixths string
+	inParens, halfDim := false, false
+	for pos := len(root); pos < len(body); {
+		rest := body[pos:]
+		if strings.ContainsRune("(), /", rune(rest[0])) {
+			switch rest[0] {
+			case '(':
+				inParens = true
+			case ')':
+				inParens = false
+			}
+			pos++
+			continue
+		}
+		if strings.HasPrefix(rest, "ø") {
+			res.Name.Quality = "m"
+			alt = append(alt, "b5")
+			halfDim = true
+			pos += len("ø")
+			continue
+		}
+		if strings.HasPrefix(rest, "no3") {
+			res.Name.Omitted = "no3"
+			pos += len("no3")
+			continue
+		}
+		var sym string
+		n := 0
+		switch {
+		case inParens:
+			if c, a, m := sixthCompound(rest, body[pos-1] == '('); m > 0 {
+				sixths, sixthAlt, n = c, a, m
+				break
+			}
+			if sym, n = prefix(rest, alterationTokens); n == 0 {
+				if sym, n = prefix(rest, addTokens); sym == "maj7" {
+					ext = append(ext, sym)
+					sym = ""
+				}
+			}
+		case len(ext) == 0 || rest[0] != 'b' && rest[0] != '#':
+			if sym, n = prefix(rest, extensionTokens); n > 0 {
+				ext = append(ext, sym)
+				sym = ""
+				break
+			}
+			fallthrough
+		default:
+			if res.Name.Quality == "" {
+				if res.Name.Quality, n = prefix(rest, qualityTokens); n > 0 {
+					break
+				}
+			}
+			sym, n = prefix(rest, alterationTokens)
+		}
+		if sym != "" {
+			alt = append(alt, sym)
+		}
+		if n == 0 {
+			return nil, &ParseError{Symbol: symbol, Pos: strings.Index(symbol, body) + pos, err: NameTokenError}
+		}
+		pos += n
+	}
+	// "ø" alone is half-diminished seventh, "ø9" keeps its extension
+	if halfDim && len(ext) == 0 {
+		ext = append(ext, "7")
+	}
+	res.Name.Extended = joinExtensions(ext)
+	res.Name.Altered = joinAlterations(alt)
+	if sixths != "" && res.Name.Altered != "" {
+		res.Name.Altered = sixths + comma + res.Name.Altered
+	} else if sixths != "" {
+		res.Name.Altered = sixths
+	}
+	if lowerRoot {
+		switch res.Name.Quality {
+		case "":
+			res.Name.Quality = "m"
+		case "m", "dim":
+		default:
+			return nil, &ParseError{Symbol: symbol, Pos: strings.Index(symbol, body), err: NameRootError}
+		}
+	}
+	intervals, optional := buildIntervals(res.Name.Quality, ext, append(alt, sixthAlt...), res.Name.Omitted != "")
+	res.Name.Intervals = intervals
+	res.Name.Degrees = degrees(intervals, res.Name)
+	res.Name.Tones = tones(res.Name)
+	res.Optional = optional
+	rootNote, _ := parseNote(root)
+	seen := make(map[int]bool)
+	for _, iv := range intervals {
+		seen[pitchClass(rootNote+iv)] = true
+	}
+	if res.Name.Bass != "" {
+		bassNote, _ := parseNote(res.Name.Bass)
+		seen[pitchClass(bassNote)] = true
+	}
+	for pc := range seen {
+		res.PitchClasses = append(res.PitchClasses, pc)
+	}
+	sort.Ints(res.PitchClasses)
+	return res, nil
+}
+
+// prefix returns symbol of the first token, which is prefix of s, and length of the token
+func prefix(s string, tokens []token) (string, int) {
+	for _, t := range tokens {
+		if strings.HasPrefix(s, t.token) {
+			return t.symbol, len(t.token)
+		}
+	}
+	return "", 0
+}
+
+// sixthCompound reads flat sixth with added ninth or eleventh, which GetNames writes as the first alteration
+// after flat root, like "b6/9/11" in "Db(b6/9/11,b5)" or "b6" in "Bb(b6,b9)". It returns the compound,
+// its alterations and its length. Single flat sixth is read only as the first alteration.
+func sixthCompound(s string, first bool) (string, []string, int) {
+	if !strings.HasPrefix(s, "b6") {
+		return "", nil, 0
+	}
+	alt := []string{"b6"}
+	n := len("b6")
+	for strings.HasPrefix(s[n:], slash) {
+		sym, m := prefix(s[n+1:], addTokens)
+		if m == 0 || sym == "maj7" {
+			break
+		}
+		alt = append(alt, sym)
+		n += 1 + m
+	}
+	if len(alt) == 1 && !first {
+		return "", nil, 0
+	}
+	return s[:n], alt, n
+}
+
+// readNote reads note letter with one optional accidental and returns it with number of read bytes
+func readNote(s string) (string, int) {
+	if s == "" || !strings.ContainsRune(naturals, rune(s[0])) {
+		return "", 0
+	}
+	if len(s) > 1 && (s[1] == '#' || s[1] == 'b') {
+		return s[:2], 2
+	}
+	return s[:1], 1
+}
+
+// bassSlash returns index of slash before bass note, or -1. Slashes in "6/9" are not followed by a letter
+func bassSlash(s string) int {
+	i := strings.LastIndexByte(s, '/')
+	if i < 0 || i+1 == len(s) || !strings.ContainsRune(naturals, rune(s[i+1])) {
+		return -1
+	}
+	return i
+}
+
+func joinExtensions(ext []string) string {
+	if len(ext) == 0 {
+		return ""
+	}
+	if ext[0] == "6" || ext[0] == "b6" {
+		return strings.Join(ext, slash)
+	}
+	return strings.Join(ext, comma)
+}
+
+// joinAlterations returns alterations in the same order as getNames does
+func joinAlterations(alt []string) string {
+	order := make(map[string]int)
+	for i, a := range nameSymbols.a {
+		order[a] = i
+	}
+	sort.SliceStable(alt, func(i, j int) bool {
+		return order[alt[i]] < order[alt[j]]
+	})
+	return strings.Join(alt, comma)
+}
+
+// buildIntervals returns required and optional intervals of chord symbol
+func buildIntervals(quality string, ext, alt []string, no3 bool) ([]int, []int) {
+	set := map[int]bool{0: true, perfectFifth: true}
+	optional := make(map[int]bool)
+	switch quality {
+	case "m":
+		set[minThird] = true
+	case "dim":
+		set[minThird], set[flatFifth], set[perfectFifth] = true, true, false
+	case "aug":
+		set[majThird], set[sharpFifth], set[perfectFifth] = true, true, false
+	case "sus2":
+		set[majSecond] = true
+	case "sus4":
+		set[perfectFourth] = true
+	default:
+		set[majThird] = !no3
+	}
+	seventh := minSeventh
+	if quality == "dim" {
+		seventh = sixth
+	}
+	sixths := len(ext) > 0 && (ext[0] == "6" || ext[0] == "b6")
+	power := false
+	for _, e := range ext {
+		switch e {
+		case "5":
+			delete(set, majThird)
+			power = true
+		case "6":
+			set[sixth] = true
+		case "b6", "b13":
+			set[flatSixth] = true
+			if e == "b13" {
+				set[seventh] = true
+			}
+		case "7":
+			set[seventh] = true
+		case "9", "11", "13":
+			set[map[string]int{"9": ninth, "11": eleventh, "13": thirteenth}[e]] = true
+			if !sixths {
+				set[seventh] = true
+			}
+			if e == "11" || e == "13" {
+				optional[ninth] = true
+			}
+			if e == "13" {
+				optional[eleventh] = true
+			}
+		default:
+			// maj7, maj9, maj11, maj13
+			set[majSeventh] = true
+			switch e {
+			case "maj9":
+				set[ninth] = true
+			case "maj11":
+				set[eleventh], optional[ninth] = true, true
+			case "maj13":
+				set[thirteenth], optional[ninth], optional[eleventh] = true, true, true
+			}
+		}
+	}
+	for _, a := range alt {
+		switch a {
+		case "b5":
+			set[flatFifth], set[perfectFifth] = true, false
+		case "#5":
+			set[sharpFifth], set[perfectFifth] = true, false
+		case "b6", "b13":
+			set[flatSixth] = true
+		case "b9", "addb9":
+			set[flatNinth] = true
+		case "#9", "add#9":
+			set[sharpNinth] = true
+		case "add9":
+			set[ninth] = true
+		case "#11", "add#11":
+			set[sharpEleventh] = true
+		case "add11":
+			set[eleventh] = true
+		}
+	}
+	var intervals, opt []int
+	for iv, ok := range set {
+		if ok {
+			intervals = append(intervals, iv)
+		}
+	}
+	for iv := range optional {
+		if !set[iv] {
+			opt = append(opt, iv)
+		}
+	}
+	// unaltered fifth is required by the name, but it can be omitted, like in C7 x32310.
+	// Power chords and chords without third have nothing else than the fifth
+	if set[perfectFifth] && !no3 && !power {
+		opt = append(opt, perfectFifth)
+	}
+	sort.Ints(intervals)
+	sort.Ints(opt)
+	return intervals, opt
+}
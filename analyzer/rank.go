@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+)
+
+// Score weights. Every chord starts with maxScore and loses points for every complication
+const (
+	maxScore      = 100
+	alteredCost   = 10
+	extendedCost  = 4
+	qualityCost   = 6
+	omittedCost   = 12
+	bassCost      = 8
+	minScoreValue = 0
+)
+
+// score returns plausibility of chord name.
+// Simple names with root close to bass get higher score: Am7 is more plausible than C6/9(#11)no3.
+// bassPos is position of root among chord notes counted from the lowest string, 0 is for bass.
+func score(name ChordName, bassPos int) int {
+	s := maxScore - bassCost*bassPos
+	if name.Altered != "" {
+		s -= alteredCost * (strings.Count(name.Altered, comma) + 1)
+	}
+	if name.Extended != "" && name.Extended != "5" {
+		s -= extendedCost * (strings.Count(name.Extended, comma) + strings.Count(name.Extended, slash) + 1)
+	}
+	if name.Quality != "" && name.Quality != "m" {
+		s -= qualityCost
+	}
+	if name.Omitted != "" {
+		s -= omittedCost
+	}
+	if s < minScoreValue {
+		return minScoreValue
+	}
+	return s
+}
+
+// rank sorts chord names by score, the most plausible first.
+// Names with equal score keep order of their roots from the lowest string.
+func rank(names []ChordName) {
+	sort.SliceStable(names, func(i, j int) bool {
+		return names[i].Score > names[j].Score
+	})
+}
+
+// bassOrder returns distinct notes of pattern from the lowest string to the highest
+func (c *nameInfo) bassOrder() []int {
+	var res []int
+	seen := make(map[int]bool)
+	for i := len(c.pattern) - 1; i >= 0; i-- {
+		if c.pattern[i] == x {
+			continue
+		}
+		note := findNote(c.tuning[i], int(c.pattern[i]), c.fret, c.capoAt(i))
+		if !seen[note] {
+			seen[note] = true
+			res = append(res, note)
+		}
+	}
+	return res
+}
+
+// inversionDegrees are chord tones, which can be in the bass of inverted chord
+var inversionDegrees = map[string]bool{"b3": true, "3": true, "b5": true, "5": true, "#5": true, "bb7": true, "b7": true, "7": true}
+
+// plain reports whether name is triad or seventh chord without alterations, which can be inverted
+func plain(name ChordName) bool {
+	switch {
+	case name.Altered != "" || name.Omitted != "":
+		return false
+	case name.Quality != "" && name.Quality != "m" && name.Quality != "dim" && name.Quality != "aug":
+		return false
+	}
+	return name.Extended == "" || name.Extended == "7" || name.Extended == "maj7"
+}
+
+// inversion returns index of the most plausible plain name, which has bass as its third, fifth or seventh,
+// when bass-rooted names[0] is not plain. Otherwise it returns 0.
+// roots are note indexes of names roots, roots[0] is bass.
+func inversion(names []ChordName, roots []int) int {
+	if plain(names[0]) {
+		return 0
+	}
+	best := 0
+	for i := 1; i < len(names); i++ {
+		if !plain(names[i]) || !inversionDegrees[degree((roots[0]-roots[i]+12)%12, names[i])] {
+			continue
+		}
+		if best == 0 || names[i].Score > names[best].Score {
+			best = i
+		}
+	}
+	return best
+}
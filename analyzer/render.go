@@ -9,6 +9,8 @@ import (
 	"image/color"
 	"image/draw"
 	"image/png"
+	"strconv"
+	"strings"
 
 	"github.com/golang/freetype"
 	"github.com/golang/freetype/truetype"
@@ -17,12 +19,23 @@ import (
 )
 
 const (
-	nameFontsize = 32
-	fontDPI      = 72
-	zero         = 0
-	cellWidth    = 100
-	cellHeight   = 60
-	fretMax      = 18
+	nameFontsize   = 32
+	superFontsize  = 20
+	superRise      = 12
+	stackFontsize  = 14
+	labelFontsize  = 14
+	labelIndent    = 4
+	fingerFontsize = 24
+	fontDPI        = 72
+	zero           = 0
+	cellWidth      = 100
+	cellHeight     = 60
+
+	barreWidth      = 30
+	capoWidth       = 10
+	capoIndent      = 6
+	boardStrings    = 6
+	boardMiddleBand = 2
 )
 const (
 	fretBoardPath = "assets/fretboard.png"
@@ -30,22 +43,36 @@ const (
 	verdanaPath   = "assets/verdana.ttf"
 )
 
+var fingerColor = color.RGBA{R: 0x1e, G: 0x1e, B: 0x1e, A: 0xff}
+
 //go:embed assets/*
 var assets embed.FS
 
 type pngInfo struct {
-	Name    string
-	Pattern string
-	Fret    int
-	Capo    bool
+	Name      []Run
+	Pattern   string
+	Fret      int
+	Span      int
+	CapoFret  int
+	Capo      []int
+	Partial   bool
+	Tuning    []string
+	Fingering string
+	Barres    []Barre
 }
 
-func newPNGInfo(name, pattern string, fret int, capo bool) *pngInfo {
+func newPNGInfo(name []Run, pattern string, fret, span, capoFret int, capo []int, partial bool, tuning []string, fingering string, barres []Barre) *pngInfo {
 	return &pngInfo{
-		Name:    name,
-		Pattern: pattern,
-		Fret:    fret,
-		Capo:    capo,
+		Name:      name,
+		Pattern:   pattern,
+		Fret:      fret,
+		Span:      span,
+		CapoFret:  capoFret,
+		Capo:      capo,
+		Partial:   partial,
+		Tuning:    tuning,
+		Fingering: fingering,
+		Barres:    barres,
 	}
 }
 
@@ -66,16 +93,24 @@ func (info *pngInfo) buildPNG() ([]byte, error) {
 	openZP := image.Pt(cellWidth, zero)
 	mutedZP := image.Pt(cellWidth*2, zero)
 	capoZP := image.Pt(cellWidth*3, zero)
-	canvas := image.NewRGBA(image.Rect(0, 0, cellWidth*6+cellWidth/2, cellHeight*7+cellHeight/2))
+	fretboard = stretchBoard(fretboard, len(tab))
+	right := cellWidth * (info.Span + 1)
+	canvas := image.NewRGBA(image.Rect(0, 0, right+cellWidth/2, cellHeight*(len(tab)+1)+cellHeight/2))
 	draw.Draw(canvas, canvas.Bounds(), fretboard, image.Pt(info.Fret*cellWidth, zero), draw.Src)
 	if info.Fret != 0 {
 		draw.Draw(canvas, image.Rect(0, 0, cellWidth, canvas.Bounds().Max.Y),
 			fretboard, image.Pt(zero, zero), draw.Src)
 	}
-	if info.Fret != fretMax {
-		draw.Draw(canvas, image.Rect(cellWidth*6+2, 0, cellWidth*6+cellWidth/2, canvas.Bounds().Max.Y),
+	if info.Fret+info.Span != boardFrets {
+		draw.Draw(canvas, image.Rect(right+2, 0, right+cellWidth/2, canvas.Bounds().Max.Y),
 			fretboard, image.Pt(zero, zero), draw.Src)
 	}
+	barreColor := image.NewUniform(sym.At(cellWidth/2, cellHeight/2))
+	for _, b := range info.Barres {
+		center := b.Fret*cellWidth + cellWidth/2
+		draw.Draw(canvas, image.Rect(center-barreWidth/2, b.From*cellHeight+cellHeight+cellHeight/2,
+			center+barreWidth/2, b.To*cellHeight+cellHeight+cellHeight/2), barreColor, image.Pt(zero, zero), draw.Over)
+	}
 	cell := image.Rect(zero, zero, cellWidth, cellHeight)
 	for i, str := range tab {
 		height := i*cellHeight + cellHeight
@@ -91,10 +126,13 @@ func (info *pngInfo) buildPNG() ([]byte, error) {
 			draw.Draw(canvas, cell, sym, fingerZP, draw.Over)
 		}
 	}
-	if info.Capo && info.Fret != 0 {
-		move(&cell, zero, cellHeight*6+cellHeight/2)
+	if info.Capo != nil && !info.capoInWindow() {
+		move(&cell, zero, cellHeight*len(tab)+cellHeight/2)
 		draw.Draw(canvas, cell, sym, capoZP, draw.Over)
 	}
+	if info.Capo != nil && (info.Partial || info.capoInWindow()) {
+		info.drawCapo(canvas, image.NewUniform(sym.At(cellWidth/2, cellHeight/2)))
+	}
 	err = info.drawText(canvas)
 	if err != nil {
 		return nil, err
@@ -102,6 +140,32 @@ func (info *pngInfo) buildPNG() ([]byte, error) {
 	dir, err := info.write(canvas)
 	return dir, err
 }
+
+// capoInWindow reports if capo is placed inside the diagram, after its first fret
+func (info *pngInfo) capoInWindow() bool {
+	return info.CapoFret > info.Fret && info.CapoFret <= info.Fret+info.Span
+}
+
+// drawCapo draws bars on the strings under capo behind its fret, one bar for every group of neighbour strings
+func (info *pngInfo) drawCapo(canvas *image.RGBA, src image.Image) {
+	left := cellWidth - capoIndent - capoWidth
+	if info.capoInWindow() {
+		left += (info.CapoFret - info.Fret) * cellWidth
+	}
+	for from := 0; from < len(info.Capo); from++ {
+		if info.Capo[from] == 0 {
+			continue
+		}
+		to := from
+		for to+1 < len(info.Capo) && info.Capo[to+1] != 0 {
+			to++
+		}
+		draw.Draw(canvas, image.Rect(left, from*cellHeight+cellHeight+cellHeight/4,
+			left+capoWidth, to*cellHeight+cellHeight*2-cellHeight/4), src, image.Pt(zero, zero), draw.Over)
+		from = to
+	}
+}
+
 func (info *pngInfo) write(img *image.RGBA) ([]byte, error) {
 	var b bytes.Buffer
 	err := png.Encode(&b, img)
@@ -118,32 +182,121 @@ func (info *pngInfo) drawText(img *image.RGBA) error {
 		return err
 	}
 	fontFace, err := freetype.ParseFont(fontData)
-	faceOptions := &truetype.Options{
-		Size:    nameFontsize,
-		DPI:     fontDPI,
-		Hinting: font.HintingNone,
+	if err != nil {
+		return err
 	}
 	fontDrawer := &font.Drawer{
-		Dst:  img,
-		Src:  image.NewUniform(color.White),
-		Face: truetype.NewFace(fontFace, faceOptions),
+		Dst: img,
+		Src: image.NewUniform(color.White),
+	}
+	info.drawName(fontDrawer, fontFace, fixed.I(img.Bounds().Max.X-cellWidth-cellWidth/2))
+	fontDrawer.Face = newFace(fontFace, labelFontsize)
+	for i, label := range info.Tuning {
+		fontDrawer.Dot = fixed.P(labelIndent, i*cellHeight+cellHeight+cellHeight/2+labelFontsize/2-2)
+		fontDrawer.DrawString(label)
 	}
-	fontDrawer.Dot = fixed.Point26_6{
-		X: fixed.I(cellWidth) + (fixed.I(img.Bounds().Max.X-cellWidth-cellWidth/2)-fontDrawer.MeasureString(info.Name))/2,
-		Y: fixed.I(cellHeight+nameFontsize) / 2,
+	if info.Capo != nil && info.CapoFret != info.Fret && !info.capoInWindow() {
+		fontDrawer.Dot = fixed.P(cellWidth-capoIndent-labelFontsize, len(info.Pattern)*cellHeight+cellHeight+labelFontsize)
+		fontDrawer.DrawString(strconv.Itoa(info.CapoFret))
+	}
+	fontDrawer.Src = image.NewUniform(fingerColor)
+	fontDrawer.Face = newFace(fontFace, fingerFontsize)
+	for i, r := range info.Fingering {
+		if r == noFinger || r == '0' || r == x {
+			continue
+		}
+		fontDrawer.Dot = fixed.Point26_6{
+			X: fixed.I(int(info.Pattern[i]-48)*cellWidth+cellWidth/2) - fontDrawer.MeasureString(string(r))/2,
+			Y: fixed.I(i*cellHeight + cellHeight + cellHeight/2 + fingerFontsize*3/8),
+		}
+		fontDrawer.DrawString(string(r))
 	}
-	fontDrawer.DrawString(info.Name)
 	return nil
 }
 
+// drawName draws runs of the name in the middle of header: superscripts are smaller and raised,
+// stacked alterations are drawn in a column. Signs missing in the font are replaced, ex: '♭' -> 'b'
+func (info *pngInfo) drawName(d *font.Drawer, f *truetype.Font, width fixed.Int26_6) {
+	base, super, stack := newFace(f, nameFontsize), newFace(f, superFontsize), newFace(f, stackFontsize)
+	measure := func(r Run) fixed.Int26_6 {
+		switch {
+		case r.Stack != nil:
+			d.Face = stack
+			var w fixed.Int26_6
+			for _, s := range r.Stack {
+				if m := d.MeasureString(s); m > w {
+					w = m
+				}
+			}
+			return w
+		case r.Super:
+			d.Face = super
+		default:
+			d.Face = base
+		}
+		return d.MeasureString(r.Text)
+	}
+	runs := make([]Run, len(info.Name))
+	var total fixed.Int26_6
+	for i, r := range info.Name {
+		runs[i] = Run{Text: fallback(f, r.Text), Super: r.Super}
+		for _, s := range r.Stack {
+			runs[i].Stack = append(runs[i].Stack, fallback(f, s))
+		}
+		total += measure(runs[i])
+	}
+	x, y := fixed.I(cellWidth)+(width-total)/2, fixed.I(cellHeight+nameFontsize)/2
+	for _, r := range runs {
+		w := measure(r)
+		switch {
+		case r.Stack != nil:
+			for i, s := range r.Stack {
+				d.Dot = fixed.Point26_6{X: x, Y: y - fixed.I((len(r.Stack)-1-i)*stackFontsize)}
+				d.DrawString(s)
+			}
+		case r.Super:
+			d.Dot = fixed.Point26_6{X: x, Y: y - fixed.I(superRise)}
+			d.DrawString(r.Text)
+		default:
+			d.Dot = fixed.Point26_6{X: x, Y: y}
+			d.DrawString(r.Text)
+		}
+		x += w
+	}
+}
+
+// fallbackSigns are drawn instead of signs, which font does not have
+var fallbackSigns = map[rune]string{'♭': "b", '♯': "#"}
+
+// fallback replaces signs missing in the font
+func fallback(f *truetype.Font, s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if sign, ok := fallbackSigns[r]; ok && f.Index(r) == 0 {
+			b.WriteString(sign)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func newFace(f *truetype.Font, size float64) font.Face {
+	return truetype.NewFace(f, &truetype.Options{
+		Size:    size,
+		DPI:     fontDPI,
+		Hinting: font.HintingNone,
+	})
+}
+
 func (info *pngInfo) toArray() (result []int, err error) {
 	for _, r := range info.Pattern {
 		if r == 'X' {
 			result = append(result, -1)
 		} else {
 			trueFret := int(r - 48)
-			if trueFret > 5 {
-				err = fmt.Errorf("fret number '%d' must be < 6", trueFret)
+			if trueFret > info.Span {
+				err = fmt.Errorf("fret number '%d' must be <= %d", trueFret, info.Span)
 				return nil, err
 			}
 			result = append(result, int(r-48))
@@ -167,6 +320,34 @@ func readPNG(path string) (*image.RGBA, error) {
 	return img, nil
 }
 
+// stretchBoard rebuilds fretboard image for any number of strings.
+// Header and fret numbers are kept, and space between strings is copied from the bands of original image,
+// so inlays stay in the middle of the neck.
+func stretchBoard(board *image.RGBA, strings int) *image.RGBA {
+	if strings == boardStrings {
+		return board
+	}
+	header := cellHeight + cellHeight/2
+	width := board.Bounds().Max.X
+	img := image.NewRGBA(image.Rect(0, 0, width, cellHeight*(strings+1)+cellHeight/2))
+	draw.Draw(img, image.Rect(0, 0, width, header), board, image.Pt(zero, zero), draw.Src)
+	mid := (strings - 2) / 2
+	for i := 0; i < strings-1; i++ {
+		band := i - mid + boardMiddleBand
+		if band < 0 {
+			band = 0
+		}
+		if band > boardStrings-2 {
+			band = boardStrings - 2
+		}
+		y := header + i*cellHeight
+		draw.Draw(img, image.Rect(0, y, width, y+cellHeight), board, image.Pt(zero, header+band*cellHeight), draw.Src)
+	}
+	y := header + (strings-1)*cellHeight
+	draw.Draw(img, image.Rect(0, y, width, y+cellHeight), board, image.Pt(zero, header+(boardStrings-1)*cellHeight), draw.Src)
+	return img
+}
+
 func move(cell *image.Rectangle, x, y int) {
 	cell.Min.X = x
 	cell.Min.Y = y
@@ -0,0 +1,338 @@
+package analyzer
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleSet is declarative form of naming rules, as it is written in YAML. See rules.yaml for the default set.
+type RuleSet struct {
+	Quality    Rule `yaml:"quality"`
+	Extension  Rule `yaml:"extension"`
+	Alteration Rule `yaml:"alteration"`
+}
+
+// Rule selects Symbol, if all of its conditions match the chord, then applies child rules:
+// only the first matching rule of First and every matching rule of All. Rule without Symbol only groups child rules.
+//
+// Has, HasAny and Lacks are intervals from root: "m2", "M2", "m3", "M3", "P4", "d5", "P5", "m6", "M6", "m7", "M7".
+// Quality and NotQuality are selected quality symbols, Extensions and NotExtensions are selected extension symbols.
+// Extended checks whether any extension is selected. FlatRoot checks whether root is spelled with flat, ex: "Bb".
+//
+// AsAlteration writes selected extensions as alteration, ex: "Bb(b6)" instead of "Bbb6".
+//
+// Text of Rule is shown by ChordName.Explain.
+type Rule struct {
+	Symbol        string   `yaml:"symbol"`
+	Text          string   `yaml:"rule"`
+	Has           []string `yaml:"has"`
+	HasAny        []string `yaml:"has-any"`
+	Lacks         []string `yaml:"lacks"`
+	Quality       []string `yaml:"quality"`
+	NotQuality    []string `yaml:"not-quality"`
+	Extensions    []string `yaml:"extensions"`
+	NotExtensions []string `yaml:"not-extensions"`
+	Extended      *bool    `yaml:"extended"`
+	FlatRoot      *bool    `yaml:"flat-root"`
+	AsAlteration  bool     `yaml:"as-alteration"`
+	First         []Rule   `yaml:"first"`
+	All           []Rule   `yaml:"all"`
+}
+
+// Rules is compiled RuleSet used by GetNames. Names of all interval sets are resolved once for every Rules.
+type Rules struct {
+	quality    *rule
+	extension  *rule
+	alteration *rule
+	table      *[12][1 << 11]ChordName
+	tableOnce  sync.Once
+}
+
+// Errors returned by NewRules and rule loaders
+var (
+	RuleIntervalError = errors.New("invalid rules: intervals must be one of m2, M2, m3, M3, P4, d5, P5, m6, M6, m7, M7")
+	RuleSymbolError   = errors.New("invalid rules: unknown symbol")
+)
+
+// qualitySymbols are names of qualities in rules, in order of filter indexes
+var qualitySymbols = []string{"sus2", "sus4", "m", "dim", "major", "aug", "no3"}
+
+// noExtension is name of empty extension in rules
+const noExtension = "none"
+
+//go:embed rules.yaml
+var defaultRulesYAML []byte
+
+var (
+	defaultRules     *Rules
+	defaultRulesOnce sync.Once
+)
+
+// DefaultRules returns embedded rule set, which is used when ChordInfo.Rules is nil
+func DefaultRules() *Rules {
+	defaultRulesOnce.Do(func() {
+		rules, err := ParseRules(defaultRulesYAML)
+		if err != nil {
+			panic(err)
+		}
+		defaultRules = rules
+	})
+	return defaultRules
+}
+
+// ParseRules compiles YAML rule set
+func ParseRules(data []byte) (*Rules, error) {
+	var set RuleSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+	return NewRules(set)
+}
+
+// LoadRules reads and compiles YAML rule set from file system, ex: embedded files of your application
+func LoadRules(fsys fs.FS, name string) (*Rules, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRules(data)
+}
+
+// LoadRulesFile reads and compiles YAML rule set from file
+func LoadRulesFile(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRules(data)
+}
+
+// NewRules compiles rule set. It returns error with RuleIntervalError or RuleSymbolError for unknown names.
+func NewRules(set RuleSet) (*Rules, error) {
+	quality, err := compileRule(set.Quality, qualitySymbols, nil)
+	if err != nil {
+		return nil, err
+	}
+	extension, err := compileRule(set.Extension, extensionSymbols(), nil)
+	if err != nil {
+		return nil, err
+	}
+	alteration, err := compileRule(set.Alteration, nameSymbols.a, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Rules{quality: quality, extension: extension, alteration: alteration}, nil
+}
+
+// rule is compiled Rule. Intervals and symbols are bit masks, index is -1 for rules without symbol
+type rule struct {
+	index         int
+	text          string
+	intervals     []int
+	has           uint16
+	hasAny        uint16
+	lacks         uint16
+	quality       uint16
+	notQuality    uint16
+	extensions    uint16
+	notExtensions uint16
+	extended      *bool
+	flatRoot      *bool
+	asAlteration  bool
+	first         []*rule
+	all           []*rule
+}
+
+// compileRule converts names into masks. Intervals of rule for tracing include intervals of parent groups,
+// which are passed in group.
+func compileRule(r Rule, symbols []string, group []int) (*rule, error) {
+	res := &rule{index: -1, text: r.Text, extended: r.Extended, flatRoot: r.FlatRoot, asAlteration: r.AsAlteration}
+	var err error
+	if r.Symbol != "" {
+		if res.index = indexOf(symbols, r.Symbol); res.index < 0 {
+			return nil, fmt.Errorf("%w: %q", RuleSymbolError, r.Symbol)
+		}
+	}
+	var has []int
+	if res.has, has, err = intervalNameMask(r.Has); err != nil {
+		return nil, err
+	}
+	if res.hasAny, _, err = intervalNameMask(r.HasAny); err != nil {
+		return nil, err
+	}
+	if res.lacks, _, err = intervalNameMask(r.Lacks); err != nil {
+		return nil, err
+	}
+	extensions := extensionSymbols()
+	for _, m := range []struct {
+		mask    *uint16
+		names   []string
+		symbols []string
+	}{
+		{&res.quality, r.Quality, qualitySymbols},
+		{&res.notQuality, r.NotQuality, qualitySymbols},
+		{&res.extensions, r.Extensions, extensions},
+		{&res.notExtensions, r.NotExtensions, extensions},
+	} {
+		if *m.mask, err = symbolMask(m.names, m.symbols); err != nil {
+			return nil, err
+		}
+	}
+	res.intervals = append(append([]int(nil), group...), has...)
+	if res.text == "" {
+		res.text = r.describe()
+	}
+	// children of selecting rule trace only their own intervals
+	children := res.intervals
+	if res.index >= 0 {
+		children = nil
+	}
+	for _, c := range r.First {
+		child, err := compileRule(c, symbols, children)
+		if err != nil {
+			return nil, err
+		}
+		res.first = append(res.first, child)
+	}
+	for _, c := range r.All {
+		child, err := compileRule(c, symbols, children)
+		if err != nil {
+			return nil, err
+		}
+		res.all = append(res.all, child)
+	}
+	return res, nil
+}
+
+// describe returns text of rule from its interval conditions
+func (r Rule) describe() string {
+	var parts []string
+	if len(r.Has) > 0 {
+		parts = append(parts, "has "+strings.Join(r.Has, ", "))
+	}
+	if len(r.HasAny) > 0 {
+		parts = append(parts, "has any of "+strings.Join(r.HasAny, ", "))
+	}
+	if len(r.Lacks) > 0 {
+		parts = append(parts, "lacks "+strings.Join(r.Lacks, ", "))
+	}
+	if len(parts) == 0 {
+		return "no other rule matched"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// extensionSymbols returns names of extensions in rules, in order of filter indexes
+func extensionSymbols() []string {
+	res := append([]string(nil), nameSymbols.e...)
+	res[onoe] = noExtension
+	return res
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// intervalNameMask converts interval names into mask and list of semitones
+func intervalNameMask(names []string) (uint16, []int, error) {
+	var mask uint16
+	var res []int
+	for _, n := range names {
+		iv := indexOf(intervalNames, n)
+		if iv <= 0 {
+			return 0, nil, fmt.Errorf("%w: %q", RuleIntervalError, n)
+		}
+		mask |= 1 << iv
+		res = append(res, iv)
+	}
+	return mask, res, nil
+}
+
+func symbolMask(names, symbols []string) (uint16, error) {
+	var mask uint16
+	for _, n := range names {
+		i := indexOf(symbols, n)
+		if i < 0 {
+			return 0, fmt.Errorf("%w: %q", RuleSymbolError, n)
+		}
+		mask |= 1 << i
+	}
+	return mask, nil
+}
+
+// selected returns mask of selected symbols of filter
+func selected(filter []bool) uint16 {
+	var mask uint16
+	for i, ok := range filter {
+		if ok {
+			mask |= 1 << i
+		}
+	}
+	return mask
+}
+
+// match checks conditions of rule on intervals mask c and already selected symbols
+func (r *rule) match(f *filters, c uint16) bool {
+	q, e := selected(f.q), selected(f.e)
+	extended := e&^(1<<onoe) != 0
+	switch {
+	case c&r.has != r.has, r.hasAny != 0 && c&r.hasAny == 0, c&r.lacks != 0:
+		return false
+	case r.quality != 0 && q&r.quality == 0, q&r.notQuality != 0:
+		return false
+	case r.extensions != 0 && e&r.extensions == 0, e&r.notExtensions != 0:
+		return false
+	case r.extended != nil && *r.extended != extended, r.flatRoot != nil && *r.flatRoot != f.flatRoot:
+		return false
+	}
+	return true
+}
+
+// apply selects symbol of rule in filter of stage and applies child rules. It is called for matching rules only
+func (r *rule) apply(f *filters, stage Stage, filter []bool, c uint16) {
+	if r.index >= 0 {
+		filter[r.index] = true
+		f.trace.add(stage, f.trace.symbol(stage, r.index), r.text, r.intervals...)
+	}
+	f.asAlteration = f.asAlteration || r.asAlteration
+	for _, child := range r.first {
+		if child.match(f, c) {
+			child.apply(f, stage, filter, c)
+			break
+		}
+	}
+	for _, child := range r.all {
+		if child.match(f, c) {
+			child.apply(f, stage, filter, c)
+		}
+	}
+}
+
+// do applies rules of all stages to intervals mask c
+func (r *Rules) do(f *filters, c uint16) {
+	for _, stage := range []struct {
+		stage  Stage
+		rule   *rule
+		filter []bool
+	}{
+		{StageQuality, r.quality, f.q},
+		{StageExtension, r.extension, f.e},
+		{StageAlteration, r.alteration, f.a},
+	} {
+		if stage.rule.match(f, c) {
+			stage.rule.apply(f, stage.stage, stage.filter, c)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// naturals stores letters in order of scale
+const naturals = "CDEFGAB"
+
+// spell returns name of chord tone, which is iv semitones higher than root and has degree label deg.
+// Letter is counted from root letter by degree number, and accidentals fill the rest:
+// in Ab major third is C, not B#, and in Cdim7 diminished seventh is Bbb, not A.
+func spell(root string, iv int, deg string) string {
+	if root == "" {
+		return ""
+	}
+	rootNote, err := parseNote(root)
+	if err != nil {
+		return ""
+	}
+	num := 1
+	if deg != "R" {
+		num, err = strconv.Atoi(strings.TrimLeft(deg, "b#"))
+		if err != nil {
+			return ""
+		}
+	}
+	letter := naturals[(strings.IndexByte(naturals, root[0])+num-1)%7]
+	natural := letters[rune(letter)]
+	acc := ((rootNote+iv-natural)%12 + 12) % 12
+	if acc > 6 {
+		acc -= 12
+	}
+	switch {
+	case acc > 0:
+		return string(letter) + strings.Repeat("#", acc)
+	case acc < 0:
+		return string(letter) + strings.Repeat("b", -acc)
+	}
+	return string(letter)
+}
+
+// tones returns spelled chord tones in order of intervals
+func tones(name ChordName) []string {
+	res := make([]string, len(name.Intervals))
+	for i, iv := range name.Intervals {
+		res[i] = spell(name.Root, iv, name.Degrees[i])
+	}
+	return res
+}
+
+// slashBass returns bass note of slash chord, spelled as chord tone, which is iv semitones higher than root
+func slashBass(name ChordName, iv int) string {
+	for i, tone := range name.Intervals {
+		if tone == iv {
+			return name.Tones[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package analyzer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NameStyle sets symbols used by BuildName.
+//
+// Qualities, Extensions and Alterations replace symbols of ChordName fields, ex: "m" -> "-" or "maj7" -> "Δ7".
+// Symbols without replacement are written as is. Quality "no3" is used for Omitted field.
+// Compound extensions like "6/9" and "9,maj9" are replaced token by token.
+//
+// HalfDiminished replaces minor quality with flat fifth alteration of minor seventh chords,
+// ex: "ø" makes "Cm7(b5)" look like "Cø7", but keeps "Cm6(b5)".
+//
+// AlterOpen and AlterClose wrap alterations, which are separated with AlterSeparator. Alterations of chords
+// without extension are always wrapped, with parentheses if AlterOpen is empty: "E(b5)" is not E flat power chord.
+//
+// LowerMinor writes root of minor and diminished chords in lower case, as in classical harmony: "c", "f#°".
+//
+// Locale names root and bass notes, ex: GermanLocale makes "F#m/C#" look like "Fis-Moll/Cis". Nil Locale is English.
+type NameStyle struct {
+	Qualities      map[string]string
+	Extensions     map[string]string
+	Alterations    map[string]string
+	HalfDiminished string
+	AlterOpen      string
+	AlterClose     string
+	AlterSeparator string
+	Slash          string
+	LowerMinor     bool
+	Locale         *Locale
+}
+
+// Built-in name styles
+var (
+	// StandardStyle is default style of BuildName: "Cmaj7", "Cm7(b5)", "C#sus4(#5,b9)"
+	StandardStyle = &NameStyle{
+		AlterOpen:      "(",
+		AlterClose:     ")",
+		AlterSeparator: comma,
+		Slash:          slash,
+	}
+	// JazzStyle uses jazz symbols: "CΔ7", "C-7", "Cø7", "C°7", "C+", "C7sus", "C7b9#11"
+	JazzStyle = &NameStyle{
+		Qualities:      map[string]string{"m": "-", "dim": "°", "aug": "+", "sus4": "sus"},
+		Extensions:     map[string]string{"maj7": "Δ7", "maj9": "Δ9", "maj11": "Δ11", "maj13": "Δ13"},
+		HalfDiminished: "ø",
+		AlterSeparator: "",
+		Slash:          slash,
+	}
+	// RealBookStyle uses Real Book spellings: "Cma7", "Cmi7", "Cmi7(b5)", "C7(b9)", "C7sus"
+	RealBookStyle = &NameStyle{
+		Qualities:      map[string]string{"m": "mi", "sus4": "sus"},
+		Extensions:     map[string]string{"maj7": "ma7", "maj9": "ma9", "maj11": "ma11", "maj13": "ma13"},
+		AlterOpen:      "(",
+		AlterClose:     ")",
+		AlterSeparator: comma,
+		Slash:          slash,
+	}
+	// BerkleeStyle uses Berklee spellings: "CMaj7", "C-7", "C-7(b5)", "Co7", "C+", "C7(b9)"
+	BerkleeStyle = &NameStyle{
+		Qualities:      map[string]string{"m": "-", "dim": "o", "aug": "+"},
+		Extensions:     map[string]string{"maj7": "Maj7", "maj9": "Maj9", "maj11": "Maj11", "maj13": "Maj13"},
+		AlterOpen:      "(",
+		AlterClose:     ")",
+		AlterSeparator: comma,
+		Slash:          slash,
+	}
+	// ClassicalStyle writes minor chords in lower case and uses classical symbols: "C", "c", "c°7", "C+", "G7"
+	ClassicalStyle = &NameStyle{
+		Qualities:      map[string]string{"m": "", "dim": "°", "aug": "+"},
+		HalfDiminished: "ø",
+		AlterOpen:      "(",
+		AlterClose:     ")",
+		AlterSeparator: comma,
+		Slash:          slash,
+		LowerMinor:     true,
+	}
+)
+
+// minorSeventh are extensions of minor seventh chords, which make half-diminished chord with flat fifth
+var minorSeventh = map[string]bool{"7": true, "9": true, "11": true, "13": true}
+
+// nameParts are symbols of chord name in the style, bass is written without slash.
+// Alterations are wrapped with open and close and separated with separator
+type nameParts struct {
+	root, quality, extended string
+	altered                 []string
+	open, close, separator  string
+	omitted, bass           string
+	sus                     bool
+}
+
+// parts returns symbols of chord name in the style
+func (s *NameStyle) parts(c *ChordName) nameParts {
+	root, quality, extended := s.Locale.Note(c.Root), c.Quality, c.Extended
+	var altered []string
+	if c.Altered != "" {
+		altered = strings.Split(c.Altered, comma)
+	}
+	if s.LowerMinor && (quality == "m" || quality == "dim") {
+		root = lower(root)
+	}
+	halfDim := false
+	if s.HalfDiminished != "" && quality == "m" && minorSeventh[extended] {
+		for i, a := range altered {
+			if a == "b5" {
+				halfDim = true
+				altered = append(altered[:i:i], altered[i+1:]...)
+				break
+			}
+		}
+	}
+	switch triad, ok := s.Locale.triad(c); {
+	case halfDim:
+		quality = s.HalfDiminished
+	case ok:
+		quality = triad
+	default:
+		quality = replace(s.Qualities, quality)
+		// flat extension after one letter root would read as accidental, like "eb6" for "Emb6"
+		if quality == "" && len(root) == 1 && strings.HasPrefix(extended, "b") {
+			quality = c.Quality
+		}
+	}
+	for i, a := range altered {
+		altered[i] = replace(s.Alterations, a)
+	}
+	p := nameParts{
+		root:      root,
+		quality:   quality,
+		extended:  replaceTokens(s.Extensions, extended),
+		altered:   altered,
+		open:      s.AlterOpen,
+		close:     s.AlterClose,
+		separator: s.AlterSeparator,
+		bass:      s.Locale.Note(c.Bass),
+		sus:       c.Quality == "sus2" || c.Quality == "sus4",
+	}
+	// alterations of chords without extension don't run into root, like "E(b5)" instead of "Eb5"
+	if p.open == "" && p.extended == "" {
+		p.open, p.close, p.separator = "(", ")", comma
+	}
+	if c.Omitted != "" {
+		p.omitted = replace(s.Qualities, c.Omitted)
+	}
+	return p
+}
+
+// build returns name of chord in the style
+func (s *NameStyle) build(c *ChordName) string {
+	p := s.parts(c)
+	name := p.root
+	if p.sus {
+		name += p.extended + p.quality
+	} else {
+		name += p.quality + p.extended
+	}
+	if len(p.altered) > 0 {
+		name += p.open + strings.Join(p.altered, p.separator) + p.close
+	}
+	name += p.omitted
+	if p.bass != "" {
+		name += s.Slash + p.bass
+	}
+	return name
+}
+
+func replace(symbols map[string]string, s string) string {
+	if r, ok := symbols[s]; ok {
+		return r
+	}
+	return s
+}
+
+// replaceTokens replaces every token of compound extension, keeping separators
+func replaceTokens(symbols map[string]string, s string) string {
+	if len(symbols) == 0 || s == "" {
+		return s
+	}
+	var b strings.Builder
+	for s != "" {
+		i := strings.IndexAny(s, comma+slash)
+		if i < 0 {
+			b.WriteString(replace(symbols, s))
+			break
+		}
+		b.WriteString(replace(symbols, s[:i]))
+		b.WriteByte(s[i])
+		s = s[i+1:]
+	}
+	return b.String()
+}
+
+// lower writes root letter in lower case, keeping accidentals
+func lower(root string) string {
+	if root == "" {
+		return root
+	}
+	return string(unicode.ToLower(rune(root[0]))) + root[1:]
+}
@@ -3,12 +3,19 @@ package analyzer
 import (
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type tabInfo struct {
-	pattern string
-	fret    int
-	capo    bool
+	pattern   string
+	fret      int
+	span      int
+	capoFret  int
+	capo      []int
+	partial   bool
+	labels    []string
+	fingering string
+	barres    []Barre
 }
 
 const (
@@ -19,14 +26,23 @@ const (
 	space        = "\u00A0"
 	doubleSpace  = space + space
 	capodastro   = "c"
+	capoLabel    = "capo "
 	finger       = "#"
+	barreSide    = "="
+	barreLink    = "=:="
 )
 
-func newTabInfo(pattern string, fret int, capo bool) *tabInfo {
+func newTabInfo(pattern string, fret, span, capoFret int, capo []int, partial bool, labels []string, fingering string, barres []Barre) *tabInfo {
 	return &tabInfo{
-		pattern: pattern,
-		fret:    fret,
-		capo:    capo,
+		pattern:   pattern,
+		fret:      fret,
+		span:      span,
+		capoFret:  capoFret,
+		capo:      capo,
+		partial:   partial,
+		labels:    labels,
+		fingering: fingering,
+		barres:    barres,
 	}
 }
 
@@ -34,32 +50,46 @@ func (c *tabInfo) buildTab(name string) string {
 	chordTab := strings.Builder{}
 	chordTab.WriteString(name)
 	chordTab.WriteRune('\n')
-	for _, fr := range c.pattern {
+	width := 0
+	for _, l := range c.labels {
+		if n := utf8.RuneCountInString(l); n > width {
+			width = n
+		}
+	}
+	for i, fr := range c.pattern {
+		chordTab.WriteString(c.labels[i])
+		chordTab.WriteString(strings.Repeat(space, width-utf8.RuneCountInString(c.labels[i])+1))
+		if c.partial {
+			if underCapo(c.capo, i) {
+				chordTab.WriteString(capodastro)
+			} else {
+				chordTab.WriteString(space)
+			}
+		}
 		switch fr {
 		case 'X':
 			chordTab.WriteString(deadEnd)
-			chordTab.WriteString(strings.Repeat(guitarString, 5))
 		case '0':
 			chordTab.WriteString(openString)
-			chordTab.WriteString(strings.Repeat(guitarString, 5))
 		default:
-			pos := int(fr - 48)
 			chordTab.WriteString(pushedString)
-			chordTab.WriteString(strings.Repeat(guitarString, pos-1))
-			chordTab.WriteString(guitarString[:1])
-			chordTab.WriteString(finger)
-			chordTab.WriteString(guitarString[2:])
-			chordTab.WriteString(strings.Repeat(guitarString, 5-pos))
+		}
+		for pos := 1; pos <= c.span; pos++ {
+			chordTab.WriteString(c.cell(i, pos))
 		}
 		chordTab.WriteRune('\n')
 	}
-	if c.capo && c.fret != 0 {
+	chordTab.WriteString(strings.Repeat(space, width+1))
+	if c.partial {
+		chordTab.WriteString(space)
+	}
+	if c.capo != nil && c.capoFret == c.fret {
 		chordTab.WriteString(capodastro)
 	} else {
 		chordTab.WriteString(space)
 	}
 	var sp string
-	for i := 1; i < 6; i++ {
+	for i := 1; i <= c.span; i++ {
 		if c.fret+i < 10 {
 			sp = space
 		} else {
@@ -69,5 +99,29 @@ func (c *tabInfo) buildTab(name string) string {
 		chordTab.WriteString(strconv.Itoa(c.fret + i))
 		chordTab.WriteString(sp)
 	}
+	if c.capo != nil && c.capoFret != c.fret {
+		chordTab.WriteRune('\n')
+		chordTab.WriteString(capoLabel)
+		chordTab.WriteString(strconv.Itoa(c.capoFret))
+	}
 	return chordTab.String()
 }
+
+// cell returns fret cell of the string, with finger or barre marker
+func (c *tabInfo) cell(str, pos int) string {
+	pushed := int(c.pattern[str]-48) == pos
+	mark := finger
+	if c.fingering != "" {
+		mark = c.fingering[str : str+1]
+	}
+	if _, ok := barreAt(c.barres, str, pos); ok {
+		if pushed {
+			return barreSide + mark + barreSide + guitarString[3:]
+		}
+		return barreLink + guitarString[3:]
+	}
+	if pushed {
+		return guitarString[:1] + mark + guitarString[2:]
+	}
+	return guitarString
+}
@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Stage describes which part of chord name is chosen by Step
+type Stage int
+
+const (
+	StageNotes Stage = iota + 1
+	StageQuality
+	StageExtension
+	StageAlteration
+	StageRoot
+	StageInversion
+)
+
+var stageNames = map[Stage]string{
+	StageNotes:      "notes",
+	StageQuality:    "quality",
+	StageExtension:  "extension",
+	StageAlteration: "alteration",
+	StageRoot:       "root",
+	StageInversion:  "inversion",
+}
+
+func (s Stage) String() string {
+	return stageNames[s]
+}
+
+// Step stores one decision of naming: chosen Symbol, Rule which chose it and Intervals which triggered it.
+// Symbol is empty for major quality and for chord without extension.
+type Step struct {
+	Stage     Stage  `json:"stage"`
+	Symbol    string `json:"symbol"`
+	Rule      string `json:"rule"`
+	Intervals []int  `json:"intervals,omitempty"`
+}
+
+// intervalNames are short names of intervals from root in semitones
+var intervalNames = []string{"P1", "m2", "M2", "m3", "M3", "P4", "d5", "P5", "m6", "M6", "m7", "M7"}
+
+// trace collects steps of naming. Methods of nil trace do nothing, so filters work without tracing
+type trace struct {
+	sym   *symbols
+	steps []Step
+}
+
+func newTrace(sym *symbols) *trace {
+	return &trace{sym: sym}
+}
+
+func (t *trace) add(stage Stage, symbol, rule string, intervals ...int) {
+	if t == nil {
+		return
+	}
+	t.steps = append(t.steps, Step{Stage: stage, Symbol: symbol, Rule: rule, Intervals: intervals})
+}
+
+// symbol returns symbol of filter index on stage
+func (t *trace) symbol(stage Stage, i int) string {
+	if t == nil {
+		return ""
+	}
+	switch stage {
+	case StageQuality:
+		return t.sym.q[i]
+	case StageExtension:
+		if i == onoe {
+			return ""
+		}
+		return t.sym.e[i]
+	}
+	return t.sym.a[i]
+}
+
+// Explain returns human-readable explanation of the name, built from Steps.
+// Steps are recorded by GetNames, when ChordInfo.Trace is true.
+func (c *ChordName) Explain() string {
+	var b strings.Builder
+	b.WriteString(c.BuildName())
+	b.WriteRune('\n')
+	if len(c.Degrees) > 0 {
+		fmt.Fprintf(&b, "notes: %s\n", strings.Join(c.Degrees, " "))
+	}
+	for _, s := range c.Steps {
+		b.WriteString(s.Stage.String())
+		if s.Symbol != "" {
+			fmt.Fprintf(&b, " %q", s.Symbol)
+		}
+		b.WriteString(": ")
+		b.WriteString(s.Rule)
+		if len(s.Intervals) > 0 {
+			names := make([]string, len(s.Intervals))
+			for i, iv := range s.Intervals {
+				names[i] = intervalNames[iv]
+			}
+			fmt.Fprintf(&b, " (%s)", strings.Join(names, ", "))
+		}
+		b.WriteRune('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,147 @@
+package analyzer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tuning stores open string notes in the same order as Pattern: from the highest string to the lowest.
+// Notes are written in letter notation with optional accidentals, like "E", "F#" or "Bb".
+//
+// Nil Tuning is treated as StandardTuning.
+type Tuning []string
+
+// Tuning presets
+var (
+	StandardTuning     = Tuning{"E", "B", "G", "D", "A", "E"}
+	DropDTuning        = Tuning{"E", "B", "G", "D", "A", "D"}
+	DoubleDropDTuning  = Tuning{"D", "B", "G", "D", "A", "D"}
+	DADGADTuning       = Tuning{"D", "A", "G", "D", "A", "D"}
+	OpenGTuning        = Tuning{"D", "B", "G", "D", "G", "D"}
+	OpenDTuning        = Tuning{"D", "A", "F#", "D", "A", "D"}
+	OpenETuning        = Tuning{"E", "B", "G#", "E", "B", "E"}
+	OpenCTuning        = Tuning{"E", "C", "G", "C", "G", "C"}
+	HalfStepDownTuning = Tuning{"Eb", "Bb", "Gb", "Db", "Ab", "Eb"}
+
+	SevenStringTuning    = Tuning{"E", "B", "G", "D", "A", "E", "B"}
+	EightStringTuning    = Tuning{"E", "B", "G", "D", "A", "E", "B", "F#"}
+	BassTuning           = Tuning{"G", "D", "A", "E"}
+	FiveStringBassTuning = Tuning{"G", "D", "A", "E", "B"}
+	UkuleleTuning        = Tuning{"A", "E", "C", "G"}
+	MandolinTuning       = Tuning{"E", "A", "D", "G"}
+)
+
+// letters stores note indexes of natural notes, counted from E like in symbols.notes
+var letters = map[rune]int{'E': 0, 'F': 1, 'G': 3, 'A': 5, 'B': 7, 'C': 8, 'D': 10}
+
+// ParseTuning parses tuning written from the lowest string to the highest, as tunings are usually written:
+// "D A D G B E", "D-A-D-G-A-D" or "DADGAD". Without separators every note must start with a capital letter.
+//
+// Returned Tuning is reversed to match Pattern order.
+func ParseTuning(s string) (Tuning, error) {
+	var fields []string
+	if strings.ContainsAny(s, " ,-") {
+		fields = strings.FieldsFunc(s, func(r rune) bool {
+			return r == ' ' || r == ',' || r == '-'
+		})
+	} else {
+		for _, r := range s {
+			if unicode.IsUpper(r) || len(fields) == 0 {
+				fields = append(fields, string(r))
+			} else {
+				fields[len(fields)-1] += string(r)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil, TuningEmptyError
+	}
+	t := make(Tuning, len(fields))
+	for i, f := range fields {
+		if _, err := parseNote(f); err != nil {
+			return nil, err
+		}
+		t[len(fields)-1-i] = strings.ToUpper(f[:1]) + f[1:]
+	}
+	return t, nil
+}
+
+// String returns tuning from the lowest string to the highest, separated with spaces
+func (t Tuning) String() string {
+	if t == nil {
+		t = StandardTuning
+	}
+	notes := make([]string, len(t))
+	for i, n := range t {
+		notes[len(t)-1-i] = n
+	}
+	return strings.Join(notes, " ")
+}
+
+func (t Tuning) notes() ([]int, error) {
+	if t == nil {
+		t = StandardTuning
+	}
+	if len(t) == 0 {
+		return nil, TuningEmptyError
+	}
+	res := make([]int, len(t))
+	for i, n := range t {
+		note, err := parseNote(n)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = note
+	}
+	return res, nil
+}
+
+// validate checks number of strings and notes of tuning
+func (t Tuning) validate() error {
+	tuning := t.labels()
+	if len(tuning) == 0 {
+		return invalid(TuningEmptyError, -1, 0, 0)
+	}
+	if len(tuning) > maxStringNumber {
+		return invalid(StringNumberError, -1, 0, maxStringNumber)
+	}
+	for i, n := range tuning {
+		if _, err := parseNote(n); err != nil {
+			var r rune
+			if n != "" {
+				r = []rune(n)[0]
+			}
+			return invalid(TuningNoteError, i, r, 0)
+		}
+	}
+	return nil
+}
+
+func (t Tuning) labels() []string {
+	if t == nil {
+		return StandardTuning
+	}
+	return t
+}
+
+// parseNote returns note index of letter note with accidentals, counted from E
+func parseNote(s string) (int, error) {
+	if s == "" {
+		return 0, TuningNoteError
+	}
+	note, ok := letters[unicode.ToUpper(rune(s[0]))]
+	if !ok {
+		return 0, TuningNoteError
+	}
+	for _, r := range s[1:] {
+		switch r {
+		case '#':
+			note++
+		case 'b':
+			note--
+		default:
+			return 0, TuningNoteError
+		}
+	}
+	return (note%12 + 12) % 12, nil
+}
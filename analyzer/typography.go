@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"html"
+	"strings"
+)
+
+// Typographic accidentals
+const (
+	flatSign  = "♭"
+	sharpSign = "♯"
+)
+
+// Run is a piece of typeset chord name. Root, quality and bass are written in normal size, extensions,
+// alterations and omissions are superscripts. Several alterations are stacked: Stack holds them from the top
+// to the bottom, and Text holds them in one line for renderers, which cannot stack.
+type Run struct {
+	Text  string
+	Super bool
+	Stack []string
+}
+
+// BuildRuns returns chord name in the given style, StandardStyle by default, as typeset runs with ♭ and ♯ signs,
+// ex: "C♯", "sus4" and stacked "♭9", "♯5" for "C#sus4(#5,b9)". Use it with BuildPNGRuns or own renderer.
+func (c *ChordName) BuildRuns(style ...*NameStyle) []Run {
+	s := StandardStyle
+	if len(style) > 0 && style[0] != nil {
+		s = style[0]
+	}
+	p := s.parts(c)
+	runs := []Run{{Text: unicodeNote(p.root)}}
+	if p.sus {
+		runs = append(runs, Run{Text: unicodeSymbol(p.extended + p.quality), Super: true})
+	} else {
+		runs = append(runs, Run{Text: unicodeSymbol(p.quality)}, Run{Text: unicodeSymbol(p.extended), Super: true})
+	}
+	if len(p.altered) > 0 {
+		altered := make([]string, len(p.altered))
+		for i, a := range p.altered {
+			altered[i] = unicodeSymbol(a)
+		}
+		run := Run{Text: p.open + strings.Join(altered, p.separator) + p.close, Super: true}
+		if len(altered) > 1 {
+			// the highest alteration is on the top
+			for i := len(altered) - 1; i >= 0; i-- {
+				run.Stack = append(run.Stack, altered[i])
+			}
+		}
+		runs = append(runs, run)
+	}
+	runs = append(runs, Run{Text: p.omitted, Super: true})
+	if p.bass != "" {
+		runs = append(runs, Run{Text: s.Slash + unicodeNote(p.bass)})
+	}
+	// empty runs are dropped and runs of the same size are joined
+	var res []Run
+	for _, r := range runs {
+		last := len(res) - 1
+		switch {
+		case r.Text == "":
+		case last >= 0 && res[last].Super == r.Super && res[last].Stack == nil && r.Stack == nil:
+			res[last].Text += r.Text
+		default:
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// BuildUnicode returns chord name in the given style with ♭ and ♯ signs, ex: "C♯sus4(♯5,♭9)"
+func (c *ChordName) BuildUnicode(style ...*NameStyle) string {
+	var b strings.Builder
+	for _, r := range c.BuildRuns(style...) {
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+// BuildHTML returns chord name in the given style as HTML with ♭ and ♯ signs and superscripts,
+// ex: "C♯<sup>sus4</sup><sup class="stack">♭9<br>♯5</sup>" for "C#sus4(#5,b9)".
+// Stacked alterations are separated with line breaks, class "stack" allows to adjust their line height.
+func (c *ChordName) BuildHTML(style ...*NameStyle) string {
+	var b strings.Builder
+	for _, r := range c.BuildRuns(style...) {
+		switch {
+		case r.Stack != nil:
+			stack := make([]string, len(r.Stack))
+			for i, s := range r.Stack {
+				stack[i] = html.EscapeString(s)
+			}
+			b.WriteString(`<sup class="stack">` + strings.Join(stack, "<br>") + "</sup>")
+		case r.Super:
+			b.WriteString("<sup>" + html.EscapeString(r.Text) + "</sup>")
+		default:
+			b.WriteString(html.EscapeString(r.Text))
+		}
+	}
+	return b.String()
+}
+
+// unicodeNote replaces accidentals at the end of note name, keeping its letters, ex: "Sib" -> "Si♭", "bb" -> "b♭"
+func unicodeNote(n string) string {
+	i := len(n)
+	for i > 1 && (n[i-1] == '#' || n[i-1] == 'b') {
+		i--
+	}
+	return n[:i] + strings.NewReplacer("#", sharpSign, "b", flatSign).Replace(n[i:])
+}
+
+// unicodeSymbol replaces sharps and flats before degrees, ex: "addb9" -> "add♭9"
+func unicodeSymbol(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '#':
+			b.WriteString(sharpSign)
+		case s[i] == 'b' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			b.WriteString(flatSign)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,263 @@
+package analyzer
+
+import "sort"
+
+// VoicingOptions sets limits of voicing search. Zero values are replaced with defaults.
+//
+// MaxFret is the highest fret to search, 12 by default.
+// MaxStretch is the number of frets covered by fingered notes, 4 by default, up to 9.
+// MinStrings is the minimum number of sounding strings, 3 by default.
+// InnerMuted allows muted strings between sounding strings.
+// RootInBass requires root on the lowest sounding string. Slash chords always have their bass there.
+// NoOptional forbids tones, which are implied by the name but not required, like the 9th in C11,
+// and requires unaltered fifth, which is omitted otherwise.
+// Rules are naming rules used to check shapes, DefaultRules if nil.
+type VoicingOptions struct {
+	Tuning     Tuning
+	Rules      *Rules
+	MaxFret    int
+	MaxStretch int
+	MinStrings int
+	InnerMuted bool
+	RootInBass bool
+	NoOptional bool
+}
+
+const (
+	voicingMaxFret    = 12
+	voicingMaxStretch = 4
+	voicingMinStrings = 3
+)
+
+type voicingInfo struct {
+	target     ChordName
+	root       int
+	bass       int
+	tuning     []int
+	allowed    map[int]bool
+	required   map[int]bool
+	frets      []int
+	options    VoicingOptions
+	candidates []voicing
+}
+
+// voicing stores found shape with its position for sorting
+type voicing struct {
+	chord   *ChordInfo
+	low     int
+	strings int
+}
+
+// Voicings returns all playable shapes of chord symbol, like "Am7" or "C/E". See ParsedChord.Voicings.
+func Voicings(symbol string, opts *VoicingOptions) ([]*ChordInfo, error) {
+	chord, err := ParseName(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return chord.Voicings(opts)
+}
+
+// Voicings returns all playable shapes of the chord name. See ParsedChord.Voicings.
+func (c *ChordName) Voicings(opts *VoicingOptions) ([]*ChordInfo, error) {
+	chord, err := ParseName(c.BuildName())
+	if err != nil {
+		return nil, err
+	}
+	chord.Name.Root = c.Root
+	return chord.Voicings(opts)
+}
+
+// Voicings returns all shapes across the neck, which have all required tones of the chord and no other notes,
+// can be fingered by SuggestFingering and are named by GetNames with the same root and symbols,
+// as base chord or chord rooted on bass.
+//
+// Shapes are sorted by position on the neck, then by number of sounding strings.
+// ChordInfo of every shape is made as in ParseFrets, with Tuning and Fingering set.
+func (c *ParsedChord) Voicings(opts *VoicingOptions) ([]*ChordInfo, error) {
+	v := &voicingInfo{target: c.Name, bass: -1}
+	if opts != nil {
+		v.options = *opts
+	}
+	if err := v.options.Tuning.validate(); err != nil {
+		return nil, err
+	}
+	v.setDefaults()
+	tuning, err := v.options.Tuning.notes()
+	if err != nil {
+		return nil, err
+	}
+	v.tuning = tuning
+	root, err := parseNote(c.Name.Root)
+	if err != nil {
+		return nil, &ParseError{Symbol: c.Name.Root, err: NameRootError}
+	}
+	v.root = root % 12
+	v.allowed, v.required = make(map[int]bool), make(map[int]bool)
+	for _, iv := range c.Name.Intervals {
+		v.allowed[(root+iv)%12], v.required[(root+iv)%12] = true, true
+	}
+	if !v.options.NoOptional {
+		for _, iv := range c.Optional {
+			v.allowed[(root+iv)%12] = true
+			delete(v.required, (root+iv)%12)
+		}
+	}
+	switch {
+	case c.Name.Bass != "":
+		bass, err := parseNote(c.Name.Bass)
+		if err != nil {
+			return nil, &ParseError{Symbol: c.Name.Bass, err: NameBassError}
+		}
+		v.bass = bass % 12
+		v.allowed[v.bass], v.required[v.bass] = true, true
+	case v.options.RootInBass:
+		v.bass = v.root
+	}
+	v.frets = make([]int, len(tuning))
+	v.search(0, 0, 0)
+	sort.SliceStable(v.candidates, func(i, j int) bool {
+		a, b := v.candidates[i], v.candidates[j]
+		if a.low != b.low {
+			return a.low < b.low
+		}
+		return a.strings > b.strings
+	})
+	res := make([]*ChordInfo, len(v.candidates))
+	for i, cand := range v.candidates {
+		res[i] = cand.chord
+	}
+	return res, nil
+}
+
+func (v *voicingInfo) setDefaults() {
+	o := &v.options
+	if o.MaxFret <= 0 || o.MaxFret > boardFrets {
+		o.MaxFret = voicingMaxFret
+	}
+	if o.MaxStretch <= 0 {
+		o.MaxStretch = voicingMaxStretch
+	}
+	if o.MaxStretch > maxSpan {
+		o.MaxStretch = maxSpan
+	}
+	if o.MinStrings <= 0 {
+		o.MinStrings = voicingMinStrings
+	}
+}
+
+// search tries every fret of the string, which gives allowed note, keeping fingered frets from low to high in stretch
+func (v *voicingInfo) search(str, low, high int) {
+	if str == len(v.tuning) {
+		v.check(low)
+		return
+	}
+	v.frets[str] = -1
+	v.search(str+1, low, high)
+	for fret := 0; fret <= v.options.MaxFret; fret++ {
+		if !v.allowed[(v.tuning[str]+fret)%12] {
+			continue
+		}
+		l, h := low, high
+		if fret > 0 {
+			if l == 0 || fret < l {
+				l = fret
+			}
+			if fret > h {
+				h = fret
+			}
+			if h-l >= v.options.MaxStretch {
+				continue
+			}
+		}
+		v.frets[str] = fret
+		v.search(str+1, l, h)
+	}
+	v.frets[str] = -1
+}
+
+// check adds current frets to candidates, if they pass string rules and are named as target
+func (v *voicingInfo) check(low int) {
+	sounding, first, last := 0, -1, -1
+	found := make(map[int]bool)
+	for i, fret := range v.frets {
+		if fret < 0 {
+			continue
+		}
+		sounding++
+		if first < 0 {
+			first = i
+		}
+		last = i
+		found[(v.tuning[i]+fret)%12] = true
+	}
+	if sounding < v.options.MinStrings || !v.matches(found) {
+		return
+	}
+	if !v.options.InnerMuted && last-first+1 != sounding {
+		return
+	}
+	if v.bass >= 0 && (v.tuning[last]+v.frets[last])%12 != v.bass {
+		return
+	}
+	frets := make([]int, len(v.frets))
+	for i, fret := range v.frets {
+		frets[len(frets)-1-i] = fret
+	}
+	chord, err := fromFrets(frets)
+	if err != nil {
+		return
+	}
+	chord.Tuning = v.options.Tuning
+	chord.Rules = v.options.Rules
+	fingering, err := chord.SuggestFingering()
+	if err != nil {
+		return
+	}
+	chord.Fingering = fingering
+	names, err := chord.GetNames()
+	if err != nil || !v.named(names) {
+		return
+	}
+	v.candidates = append(v.candidates, voicing{chord: chord, low: low, strings: sounding})
+}
+
+// named reports whether base chord or chord rooted on bass has the same root and symbols as target,
+// and the same bass, if target has it. Other variations are not checked: they name the same notes
+// on every root, like C6 for Am7.
+func (v *voicingInfo) named(names *ChordNames) bool {
+	for _, name := range []ChordName{names.Base, names.BassRooted} {
+		root, err := parseNote(name.Root)
+		if err != nil || root%12 != v.root || !sameSymbols(&name, &v.target) {
+			continue
+		}
+		if v.target.Bass == "" {
+			return true
+		}
+		if bass, err := parseNote(name.Bass); err == nil && bass%12 == v.bass {
+			return true
+		}
+	}
+	return false
+}
+
+// sameSymbols reports whether names have the same quality, extensions, alterations and omissions.
+// Flat sixth compounds are compared by their alterations, so "Bb(b6/9)" and "Bb(b6,add9)" match.
+func sameSymbols(a, b *ChordName) bool {
+	return a.Quality == b.Quality && a.Extended == b.Extended && a.Omitted == b.Omitted &&
+		joinAlterations(splitAlterations(a.Altered)) == joinAlterations(splitAlterations(b.Altered))
+}
+
+// matches reports whether notes have all required tones and only allowed ones
+func (v *voicingInfo) matches(notes map[int]bool) bool {
+	for note := range v.required {
+		if !notes[note] {
+			return false
+		}
+	}
+	for note := range notes {
+		if !v.allowed[note] {
+			return false
+		}
+	}
+	return true
+}